@@ -0,0 +1,16 @@
+package libparser
+
+// An environment variable that is only set for a single command, e.g. 'GOOS=linux go build'
+type NodeAssignment struct {
+	Name  string
+	Value *NodeString
+	NodeContext
+}
+
+func (node *NodeAssignment) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeAssignment) String() string {
+	return node.Name + "=" + node.Value.String()
+}
@@ -0,0 +1,31 @@
+package libparser
+
+// Runs a statement, pipeline or a chain without waiting for it to finish, written with a trailing '&'
+type NodeBackground struct {
+	Node Node
+	NodeContext
+}
+
+func (node *NodeBackground) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeBackground) String() string {
+	return node.Node.String() + " &"
+}
+
+// ————————————————————————————————
+
+// Waits for background jobs to finish, all of them if no arguments are given
+type NodeWait struct {
+	NodeContext
+	NodeArgs
+}
+
+func (node *NodeWait) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeWait) String() string {
+	return "wait" + node.NodeArgs.String()
+}
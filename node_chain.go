@@ -0,0 +1,26 @@
+package libparser
+
+type ChainOperator string
+
+const (
+	CHAIN_AND ChainOperator = "&&"
+	CHAIN_OR  ChainOperator = "||"
+)
+
+// Runs [NodeChain.Right] depending on the exit status of [NodeChain.Left]
+type NodeChain struct {
+	Left     Node
+	Operator ChainOperator
+	Right    Node
+	NodeContext
+}
+
+func (node *NodeChain) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeChain) String() string {
+	return node.Left.String() + " " +
+		string(node.Operator) + " " +
+		node.Right.String()
+}
@@ -4,6 +4,8 @@ import "fmt"
 
 type NodeComment struct {
 	Contents string
+	// Follows a statement on the same line
+	IsInline bool
 	NodeContext
 }
 
@@ -0,0 +1,53 @@
+package libparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An ':if' directive linked with the ':elif' and ':else' directives that follow it
+type NodeConditional struct {
+	// Branches in the order they were written. Each one keeps its condition in NodeArgs
+	// and its body in NodeChildren, the ':else' branch has no condition.
+	Branches []*NodeDirective
+	NodeContext
+}
+
+func (node *NodeConditional) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeConditional) String() string {
+	branches := make([]string, len(node.Branches))
+	for i, branch := range node.Branches {
+		branches[i] = branch.String()
+	}
+	return strings.Join(branches, " ")
+}
+
+// Returns the first branch whose condition is true or the ':else' branch,
+// nil if there is no such branch.
+func (node *NodeConditional) Match(locals Locals, options *EvalOptions) (*NodeDirective, error) {
+	for _, branch := range node.Branches {
+		if branch.Name == "else" {
+			return branch, nil
+		}
+
+		if len(branch.NodeArgs) != 1 {
+			return nil, fmt.Errorf("':%s' expects a single condition", branch.Name)
+		}
+		condition, ok := branch.NodeArgs[0].(*NodeExpr)
+		if !ok {
+			return nil, fmt.Errorf("':%s' has a condition of unexpected type %T", branch.Name, branch.NodeArgs[0])
+		}
+
+		matches, err := condition.Eval(locals, options)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			return branch, nil
+		}
+	}
+	return nil, nil
+}
@@ -1,7 +1,8 @@
 package libparser
 
 type NodeExec struct {
-	Name string
+	Env  []*NodeAssignment
+	Name *NodeString
 	NodeContext
 	NodeArgs
 }
@@ -11,6 +12,15 @@ func (node *NodeExec) Context() NodeContext {
 }
 
 func (node *NodeExec) String() string {
-	return node.Name +
+	var prefix string
+	for _, assignment := range node.Env {
+		prefix += assignment.String() + " "
+	}
+	return prefix + node.Name.String() +
 		node.NodeArgs.String()
 }
+
+// Returns the name of the command if it doesn't contain any variables or substitutions
+func (node *NodeExec) LiteralName() (string, bool) {
+	return node.Name.Literal()
+}
@@ -0,0 +1,142 @@
+package libparser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type ExprOperator string
+
+const (
+	EXPR_OR        ExprOperator = "||"
+	EXPR_AND       ExprOperator = "&&"
+	EXPR_NOT       ExprOperator = "!"
+	EXPR_EQUAL     ExprOperator = "=="
+	EXPR_NOT_EQUAL ExprOperator = "!="
+	EXPR_LESS      ExprOperator = "<"
+	EXPR_GREATER   ExprOperator = ">"
+)
+
+// Binary operators and how tightly they bind, higher binds tighter
+var EXPR_PRECEDENCE = map[ExprOperator]int{
+	EXPR_OR:        1,
+	EXPR_AND:       2,
+	EXPR_EQUAL:     3,
+	EXPR_NOT_EQUAL: 3,
+	EXPR_LESS:      3,
+	EXPR_GREATER:   3,
+}
+
+// Directives whose arguments are parsed as a single [NodeExpr]
+var EXPRESSION_DIRECTIVES = []string{"if", "elif", "assert"}
+
+// A boolean expression used as the condition of a directive, e.g. ':if $target == "linux" && $debug'
+type NodeExpr struct {
+	// Empty when the expression is a single operand
+	Operator ExprOperator
+	// [EXPR_NOT] only uses Left
+	Left, Right *NodeExpr
+	// A string or a number when there is no operator
+	Operand *NodeString
+	// Whether the expression was written inside of '(...)'
+	IsGrouped bool
+	NodeContext
+}
+
+func (node *NodeExpr) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeExpr) String() string {
+	var out string
+	switch node.Operator {
+	case "":
+		out = node.Operand.String()
+	case EXPR_NOT:
+		out = "! " + node.Left.String()
+	default:
+		out = node.Left.String() + " " + string(node.Operator) + " " + node.Right.String()
+	}
+
+	if node.IsGrouped {
+		return "(" + out + ")"
+	}
+	return out
+}
+
+// Evaluates the expression. A single operand is true unless it's empty, "0" or "false".
+// '<' and '>' compare numbers if both sides are numbers and strings otherwise.
+func (node *NodeExpr) Eval(locals Locals, options *EvalOptions) (bool, error) {
+	switch node.Operator {
+
+	case "":
+		value, err := node.Operand.Eval(locals, options)
+		if err != nil {
+			return false, err
+		}
+		return isTruthy(value), nil
+
+	case EXPR_NOT:
+		value, err := node.Left.Eval(locals, options)
+		return !value, err
+
+	case EXPR_AND, EXPR_OR:
+		left, err := node.Left.Eval(locals, options)
+		if err != nil {
+			return false, err
+		}
+		// Short-circuits the same way a shell does it
+		if left == (node.Operator == EXPR_OR) {
+			return left, nil
+		}
+		return node.Right.Eval(locals, options)
+	}
+
+	left, err := node.Left.value(locals, options)
+	if err != nil {
+		return false, err
+	}
+	right, err := node.Right.value(locals, options)
+	if err != nil {
+		return false, err
+	}
+
+	switch node.Operator {
+	case EXPR_EQUAL:
+		return left == right, nil
+	case EXPR_NOT_EQUAL:
+		return left != right, nil
+	case EXPR_LESS, EXPR_GREATER:
+		left_number, left_err := strconv.ParseFloat(left, 64)
+		right_number, right_err := strconv.ParseFloat(right, 64)
+		if left_err == nil && right_err == nil {
+			if node.Operator == EXPR_LESS {
+				return left_number < right_number, nil
+			}
+			return left_number > right_number, nil
+		}
+		if node.Operator == EXPR_LESS {
+			return left < right, nil
+		}
+		return left > right, nil
+	}
+
+	return false, fmt.Errorf("unknown operator %q", node.Operator)
+}
+
+// Returns the string value of an operand, or "1"/"0" for any other expression
+func (node *NodeExpr) value(locals Locals, options *EvalOptions) (string, error) {
+	if node.Operator == "" {
+		return node.Operand.Eval(locals, options)
+	}
+	value, err := node.Eval(locals, options)
+	return boolToString(value), err
+}
+
+func isTruthy(value string) bool {
+	switch value {
+	case "", boolToString(false), "false", "FALSE":
+		return false
+	}
+	return true
+}
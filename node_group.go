@@ -0,0 +1,35 @@
+package libparser
+
+import "strings"
+
+// Statements grouped with '{ ... }', or with '( ... )' to run them in a subshell.
+// Redirections and pipes apply to the group as a whole.
+type NodeGroup struct {
+	IsSubshell bool
+	NodeContext
+	NodeChildren
+}
+
+func (node *NodeGroup) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeGroup) String() string {
+	opening, closing := "{", "}"
+	if node.IsSubshell {
+		opening, closing = "(", ")"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(opening)
+
+	for _, child := range node.NodeChildren {
+		if _, ok := child.(*NodeWhitespace); ok {
+			continue
+		}
+		builder.WriteString(" " + child.String() + ";")
+	}
+
+	builder.WriteString(" " + closing)
+	return builder.String()
+}
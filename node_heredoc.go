@@ -0,0 +1,42 @@
+package libparser
+
+import "strings"
+
+type NodeHeredoc struct {
+	Delimiter string
+	// Leading tabs are stripped from every line, written as '<<-'
+	StripTabs bool
+	// The delimiter was quoted, meaning that the body isn't interpolated
+	IsLiteral bool
+	Body      *NodeString
+	NodeContext
+}
+
+func (node *NodeHeredoc) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeHeredoc) String() string {
+	return node.header() + "\n" + node.body()
+}
+
+func (node *NodeHeredoc) header() string {
+	var builder strings.Builder
+	builder.WriteString("<<")
+	if node.StripTabs {
+		builder.WriteString("-")
+	}
+	if node.IsLiteral {
+		builder.WriteString("'" + node.Delimiter + "'")
+	} else {
+		builder.WriteString(node.Delimiter)
+	}
+	return builder.String()
+}
+
+func (node *NodeHeredoc) body() string {
+	if node.Body == nil {
+		return node.Delimiter
+	}
+	return node.Body.Segments.String() + node.Delimiter
+}
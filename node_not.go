@@ -0,0 +1,15 @@
+package libparser
+
+// Inverts the exit status of a statement or a pipeline, written with a leading '!'
+type NodeNot struct {
+	Node Node
+	NodeContext
+}
+
+func (node *NodeNot) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeNot) String() string {
+	return "! " + node.Node.String()
+}
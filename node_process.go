@@ -0,0 +1,25 @@
+package libparser
+
+type ProcessDirection string
+
+const (
+	// '<(...)' reads the output of the statement
+	PROCESS_OUTPUT ProcessDirection = "<"
+	// '>(...)' writes into the input of the statement
+	PROCESS_INPUT ProcessDirection = ">"
+)
+
+// An argument that is replaced with the path of a pipe connected to the statement
+type NodeProcessSubst struct {
+	Direction ProcessDirection
+	Node      Node
+	NodeContext
+}
+
+func (node *NodeProcessSubst) Context() NodeContext {
+	return node.NodeContext
+}
+
+func (node *NodeProcessSubst) String() string {
+	return string(node.Direction) + "(" + node.Node.String() + ")"
+}
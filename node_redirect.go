@@ -1,12 +1,62 @@
 package libparser
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+type RedirectMode string
+
+const (
+	REDIRECT_READ            RedirectMode = "<"
+	REDIRECT_WRITE           RedirectMode = ">"
+	REDIRECT_APPEND          RedirectMode = ">>"
+	REDIRECT_DUPLICATE       RedirectMode = ">&"
+	REDIRECT_DUPLICATE_INPUT RedirectMode = "<&"
+	REDIRECT_HEREDOC         RedirectMode = "<<"
+	REDIRECT_HERESTRING      RedirectMode = "<<<"
+)
+
+type RedirectOperation struct {
+	Fd   uint
+	Mode RedirectMode
+	// [*NodeString] for files and here-strings, [*NodeHeredoc] for heredocs, nil for duplicates
+	Target Node
+	// Only used by [REDIRECT_DUPLICATE] and [REDIRECT_DUPLICATE_INPUT]
+	TargetFd uint
+}
+
+func (operation RedirectOperation) String() string {
+	var prefix string
+	if operation.Fd != operation.Mode.DefaultFd() {
+		prefix = fmt.Sprint(operation.Fd)
+	}
+
+	switch operation.Mode {
+	case REDIRECT_DUPLICATE, REDIRECT_DUPLICATE_INPUT:
+		return fmt.Sprintf("%s%s%d", prefix, operation.Mode, operation.TargetFd)
+	case REDIRECT_HEREDOC:
+		return prefix + operation.Target.(*NodeHeredoc).header()
+	}
+
+	return prefix + string(operation.Mode) + operation.Target.String()
+}
+
+// The file descriptor that is used when it's omitted, e.g. '>' is the same as '1>'
+func (mode RedirectMode) DefaultFd() uint {
+	switch mode {
+	case REDIRECT_READ, REDIRECT_DUPLICATE_INPUT, REDIRECT_HEREDOC, REDIRECT_HERESTRING:
+		return 0
+	}
+	return 1
+}
+
+// ————————————————————————————————
 
 type NodeRedirect struct {
 	Source Node
-	Stdin  *NodeString
-	Stdout *NodeString
-	Stderr *NodeString
+	// Applied in order, e.g. '>out.log 2>&1' is not the same as '2>&1 >out.log'
+	Operations []RedirectOperation
 	NodeContext
 }
 
@@ -16,14 +66,55 @@ func (node *NodeRedirect) Context() NodeContext {
 
 func (node *NodeRedirect) String() string {
 	var builder strings.Builder
-	node.printIfNotNil(&builder, node.Stdin, " <")
-	node.printIfNotNil(&builder, node.Stdout, " >")
-	node.printIfNotNil(&builder, node.Stderr, " >>")
-	return node.Source.String() + builder.String()
+	builder.WriteString(node.Source.String())
+
+	heredocs := []*NodeHeredoc{}
+	for _, operation := range node.Operations {
+		builder.WriteString(" " + operation.String())
+		if heredoc, ok := operation.Target.(*NodeHeredoc); ok {
+			heredocs = append(heredocs, heredoc)
+		}
+	}
+
+	for _, heredoc := range heredocs {
+		builder.WriteString("\n" + heredoc.body())
+	}
+
+	return builder.String()
+}
+
+// The file that stdin is read from, if any
+func (node *NodeRedirect) Stdin() *NodeString {
+	return node.files()[0]
+}
+
+// The file that stdout is written to, if any
+func (node *NodeRedirect) Stdout() *NodeString {
+	return node.files()[1]
 }
 
-func (node *NodeRedirect) printIfNotNil(b *strings.Builder, target *NodeString, prefix string) {
-	if target != nil {
-		b.WriteString(prefix + target.String())
+// The file that stderr is written to, if any
+func (node *NodeRedirect) Stderr() *NodeString {
+	return node.files()[2]
+}
+
+// Resolves the file that each file descriptor ends up pointing to after all operations
+func (node *NodeRedirect) files() map[uint]*NodeString {
+	files := map[uint]*NodeString{}
+
+	for _, operation := range node.Operations {
+		switch operation.Mode {
+
+		case REDIRECT_DUPLICATE, REDIRECT_DUPLICATE_INPUT:
+			files[operation.Fd] = files[operation.TargetFd]
+
+		case REDIRECT_READ, REDIRECT_WRITE, REDIRECT_APPEND:
+			files[operation.Fd], _ = operation.Target.(*NodeString)
+
+		default:
+			delete(files, operation.Fd)
+		}
 	}
+
+	return files
 }
@@ -2,11 +2,30 @@ package libparser
 
 import (
 	"fmt"
+	"io/fs"
+	"slices"
 	"strings"
 )
 
+// Services of the runtime that strings are evaluated with. A nil *EvalOptions uses the defaults.
+type EvalOptions struct {
+	// Executes command substitutions, they fail to evaluate if nil
+	Runner CommandRunner
+	// The filesystem that glob patterns are matched against, the OS filesystem is used if nil.
+	// Absolute patterns are resolved from its root.
+	GlobFS fs.FS
+	// What happens when a glob pattern doesn't match any files
+	GlobNoMatch GlobNoMatchPolicy
+	// Expands '~' and '~user', the home directories of the OS are used if nil
+	LookupHomeDir HomeDirLookup
+}
+
+// ————————————————————————————————
+
 type NodeString struct {
 	Segments SegmentedString
+	// The original spelling including quotes and escape sequences, empty if it wasn't parsed
+	Raw string
 	NodeContext
 }
 
@@ -14,19 +33,29 @@ func (node *NodeString) Context() NodeContext {
 	return node.NodeContext
 }
 
+// Braces, globs and tildes only expand outside of quotes,
+// so if there are any, only the segments around them are quoted.
 func (node *NodeString) String() string {
-	value := node.Segments.String()
-	if ShouldStringBeQuoted(value) {
-		return fmt.Sprintf("\"%s\"", node.Segments.String())
+	if !slices.ContainsFunc(node.Segments, isUnquotedSegment) {
+		return quoteSegments(node.Segments)
+	}
+
+	var builder strings.Builder
+	for _, segment := range node.Segments {
+		if isUnquotedSegment(segment) {
+			builder.WriteString(segment.Segment())
+		} else {
+			builder.WriteString(quoteSegments(SegmentedString{segment}))
+		}
 	}
-	return value
+	return builder.String()
 }
 
-func (node *NodeString) Eval(locals Locals) (string, error) {
+func (node *NodeString) Eval(locals Locals, options *EvalOptions) (string, error) {
 	var builder strings.Builder
 
 	for _, segment := range node.Segments {
-		part, err := segment.Eval(locals)
+		part, err := segment.Eval(locals, options)
 		if err != nil {
 			return "", err
 		}
@@ -36,6 +65,42 @@ func (node *NodeString) Eval(locals Locals) (string, error) {
 	return builder.String(), nil
 }
 
+// Evaluates the string into a list of words,
+// expanding [BraceStringSegment] and then glob patterns against [EvalOptions.GlobFS]
+func (node *NodeString) EvalWords(locals Locals, options *EvalOptions) ([]string, error) {
+	words, err := appendWordSegments([]expandingWord{{}}, node.Segments, locals, options)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []string{}
+	for _, word := range words {
+		if !word.has_glob {
+			out = append(out, word.literal)
+			continue
+		}
+		matches, err := expandGlob(word.pattern, word.literal, options)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, matches...)
+	}
+	return out, nil
+}
+
+// Returns the contents if the string only consists of [LiteralStringSegment]
+func (node *NodeString) Literal() (string, bool) {
+	var builder strings.Builder
+	for _, segment := range node.Segments {
+		literal, ok := segment.(*LiteralStringSegment)
+		if !ok {
+			return "", false
+		}
+		builder.WriteString(literal.Contents)
+	}
+	return builder.String(), true
+}
+
 func NewSimpleNodeString(contents string) *NodeString {
 	return &NodeString{
 		Segments: SegmentedString{
@@ -50,7 +115,7 @@ func NewSimpleNodeString(contents string) *NodeString {
 
 type StringSegment interface {
 	Segment() string
-	Eval(Locals) (string, error)
+	Eval(Locals, *EvalOptions) (string, error)
 }
 
 // ————————————————————————————————
@@ -75,7 +140,7 @@ func (segment *LiteralStringSegment) Segment() string {
 	return segment.Contents
 }
 
-func (segment *LiteralStringSegment) Eval(_ Locals) (string, error) {
+func (segment *LiteralStringSegment) Eval(_ Locals, _ *EvalOptions) (string, error) {
 	return segment.Contents, nil
 }
 
@@ -85,46 +150,134 @@ type VariableStringSegment struct {
 	Name       string
 	Modifiers  []StringModifier
 	IsOptional bool
+	// A single element of a list, negative indices count from the end, '${name[0]}'
+	Index *int
+	// Expands a list into a separate word per element, '${name[@]}'
+	IsSplat bool
+	// Used when the variable is unset or empty, '${name?=default}'.
+	// It's also assigned to the variable unless the locals are nil.
+	Default *NodeString
+	// Used instead of the value when the variable is set, even if it's empty, '${name?+alternate}'
+	Alternate *NodeString
+	// Fails the evaluation when the variable is unset, '${name?!message}'
+	ErrorMessage *NodeString
 }
 
 func (segment *VariableStringSegment) Segment() string {
-	if len(segment.Modifiers) == 0 {
-		if segment.IsOptional {
-			return fmt.Sprintf("${%s?}", segment.Name)
-		}
-		return fmt.Sprintf("$%s", segment.Name)
+	name := segment.Name
+	switch {
+	case segment.IsSplat:
+		name += "[@]"
+	case segment.Index != nil:
+		name += fmt.Sprintf("[%d]", *segment.Index)
+	}
+
+	switch {
+	case segment.Default != nil:
+		return fmt.Sprintf("${%s?=%s}", name, segment.Default.Segments.String())
+	case segment.Alternate != nil:
+		return fmt.Sprintf("${%s?+%s}", name, segment.Alternate.Segments.String())
+	case segment.ErrorMessage != nil:
+		return fmt.Sprintf("${%s?!%s}", name, segment.ErrorMessage.Segments.String())
 	}
 
-	name := segment.Name
 	if segment.IsOptional {
 		name += "?"
 	}
+	if len(segment.Modifiers) == 0 && name == segment.Name {
+		return fmt.Sprintf("$%s", name)
+	}
 
 	var builder strings.Builder
 	for _, modifier := range segment.Modifiers {
 		builder.WriteString(":" + modifier.String())
 	}
 
-	return fmt.Sprintf("${%s%s}", segment.Name, builder.String())
+	return fmt.Sprintf("${%s%s}", name, builder.String())
 }
 
-func (segment *VariableStringSegment) Eval(locals Locals) (string, error) {
-	value, exists := locals[segment.Name]
+// Evaluates the variable into a single string, elements of a list are joined with spaces
+func (segment *VariableStringSegment) Eval(locals Locals, options *EvalOptions) (string, error) {
+	values, err := segment.EvalList(locals, options)
+	return strings.Join(values, " "), err
+}
+
+// Evaluates the variable into a list of values, a string being a list of a single element.
+// Modifiers are applied to each element unless they work on the whole list, e.g. 'length'.
+func (segment *VariableStringSegment) EvalList(locals Locals, options *EvalOptions) ([]string, error) {
+	values, exists := locals.List(segment.Name)
+	is_list := locals[segment.Name].IsList()
+	is_set := exists && !isEmptyList(values)
+
+	switch {
+
+	case segment.Alternate != nil:
+		if !exists {
+			return []string{}, nil
+		}
+		value, err := segment.Alternate.Eval(locals, options)
+		return []string{value}, err
+
+	case segment.Default != nil && !is_set:
+		fallback, err := segment.Default.Eval(locals, options)
+		if err != nil {
+			return nil, err
+		}
+		if locals != nil {
+			locals[segment.Name] = StringValue(fallback)
+		}
+		values, exists, is_list = []string{fallback}, true, false
+
+	case segment.ErrorMessage != nil && !exists:
+		message, err := segment.ErrorMessage.Eval(locals, options)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("variable %q is not set: %s", segment.Name, message)
+	}
+
 	if !exists {
 		if segment.IsOptional {
-			return "", nil
+			return []string{}, nil
 		}
-		return segment.Name, fmt.Errorf(
+		return []string{segment.Name}, fmt.Errorf(
 			"variable %q is not defined in the current scope",
 			segment.Name,
 		)
 	}
 
+	if segment.Index != nil {
+		index := *segment.Index
+		if index < 0 {
+			index += len(values)
+		}
+		if index < 0 || index >= len(values) {
+			return nil, fmt.Errorf(
+				"index %d is out of range for variable %q with %d elements",
+				*segment.Index,
+				segment.Name,
+				len(values),
+			)
+		}
+		values, is_list = values[index:index+1], false
+	}
+
+	values = slices.Clone(values)
 	for _, modifier := range segment.Modifiers {
-		value = modifier.Call(locals, value)
+		if is_list && modifier.CallList != nil {
+			values, is_list = []string{modifier.CallList(locals, options, values)}, false
+			continue
+		}
+		for i := range values {
+			values[i] = modifier.Call(locals, options, values[i])
+		}
 	}
 
-	return value, nil
+	return values, nil
+}
+
+func isEmptyList(values []string) bool {
+	return len(values) == 0 || (len(values) == 1 && values[0] == "")
 }
 
 // ————————————————————————————————
@@ -135,3 +288,40 @@ func ShouldStringBeQuoted(value string) bool {
 	}
 	return strings.ContainsAny(value, " \"'`[]{}")
 }
+
+func isUnquotedSegment(segment StringSegment) bool {
+	switch segment.(type) {
+	case *BraceStringSegment, *GlobStringSegment, *TildeStringSegment:
+		return true
+	}
+	return false
+}
+
+var quotedLiteralEscapes = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// Wraps the segments in double quotes if they would be read differently without them.
+// Literals that look like a glob or a tilde are quoted as well, so they stay literal.
+func quoteSegments(segments SegmentedString) string {
+	value := segments.String()
+	is_quoted := ShouldStringBeQuoted(value)
+	for _, segment := range segments {
+		if literal, ok := segment.(*LiteralStringSegment); ok && strings.ContainsAny(literal.Contents, "*?~") {
+			is_quoted = true
+		}
+	}
+	if !is_quoted {
+		return value
+	}
+
+	var builder strings.Builder
+	builder.WriteRune('"')
+	for _, segment := range segments {
+		if literal, ok := segment.(*LiteralStringSegment); ok {
+			builder.WriteString(quotedLiteralEscapes.Replace(literal.Contents))
+		} else {
+			builder.WriteString(segment.Segment())
+		}
+	}
+	builder.WriteRune('"')
+	return builder.String()
+}
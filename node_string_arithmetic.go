@@ -0,0 +1,204 @@
+package libparser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type ArithmeticOperator string
+
+const (
+	ARITHMETIC_ADD        ArithmeticOperator = "+"
+	ARITHMETIC_SUBTRACT   ArithmeticOperator = "-"
+	ARITHMETIC_MULTIPLY   ArithmeticOperator = "*"
+	ARITHMETIC_DIVIDE     ArithmeticOperator = "/"
+	ARITHMETIC_MODULO     ArithmeticOperator = "%"
+	ARITHMETIC_LESS       ArithmeticOperator = "<"
+	ARITHMETIC_LESS_EQ    ArithmeticOperator = "<="
+	ARITHMETIC_GREATER    ArithmeticOperator = ">"
+	ARITHMETIC_GREATER_EQ ArithmeticOperator = ">="
+	ARITHMETIC_EQUAL      ArithmeticOperator = "=="
+	ARITHMETIC_NOT_EQUAL  ArithmeticOperator = "!="
+)
+
+// Binary operators and how tightly they bind, higher binds tighter
+var ARITHMETIC_PRECEDENCE = map[ArithmeticOperator]int{
+	ARITHMETIC_EQUAL:      1,
+	ARITHMETIC_NOT_EQUAL:  1,
+	ARITHMETIC_LESS:       2,
+	ARITHMETIC_LESS_EQ:    2,
+	ARITHMETIC_GREATER:    2,
+	ARITHMETIC_GREATER_EQ: 2,
+	ARITHMETIC_ADD:        3,
+	ARITHMETIC_SUBTRACT:   3,
+	ARITHMETIC_MULTIPLY:   4,
+	ARITHMETIC_DIVIDE:     4,
+	ARITHMETIC_MODULO:     4,
+}
+
+// ————————————————————————————————
+
+// An expansion of '$((...))' that evaluates to a decimal integer
+type ArithmeticStringSegment struct {
+	Expression ArithmeticNode
+}
+
+func (segment *ArithmeticStringSegment) Segment() string {
+	return fmt.Sprintf("$((%s))", segment.Expression.String())
+}
+
+func (segment *ArithmeticStringSegment) Eval(locals Locals, _ *EvalOptions) (string, error) {
+	value, err := segment.Expression.Eval(locals)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(value, 10), nil
+}
+
+// ————————————————————————————————
+
+type ArithmeticNode interface {
+	String() string
+	Eval(Locals) (int64, error)
+}
+
+type ArithmeticNumber struct {
+	Value int64
+}
+
+func (node *ArithmeticNumber) String() string {
+	return strconv.FormatInt(node.Value, 10)
+}
+
+func (node *ArithmeticNumber) Eval(_ Locals) (int64, error) {
+	return node.Value, nil
+}
+
+// ————————————————————————————————
+
+// A variable referenced by its name, with or without '$'
+type ArithmeticVariable struct {
+	Name string
+}
+
+func (node *ArithmeticVariable) String() string {
+	return node.Name
+}
+
+func (node *ArithmeticVariable) Eval(locals Locals) (int64, error) {
+	value, exists := locals.Get(node.Name)
+	if !exists {
+		return 0, fmt.Errorf(
+			"variable %q is not defined in the current scope",
+			node.Name,
+		)
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("variable %q is not an integer: %q", node.Name, value)
+	}
+	return number, nil
+}
+
+// ————————————————————————————————
+
+// '(...)' inside of an arithmetic expression
+type ArithmeticGroup struct {
+	Inner ArithmeticNode
+}
+
+func (node *ArithmeticGroup) String() string {
+	return "(" + node.Inner.String() + ")"
+}
+
+func (node *ArithmeticGroup) Eval(locals Locals) (int64, error) {
+	return node.Inner.Eval(locals)
+}
+
+// ————————————————————————————————
+
+// A leading '-' or '+'
+type ArithmeticUnary struct {
+	Operator ArithmeticOperator
+	Operand  ArithmeticNode
+}
+
+func (node *ArithmeticUnary) String() string {
+	return string(node.Operator) + node.Operand.String()
+}
+
+func (node *ArithmeticUnary) Eval(locals Locals) (int64, error) {
+	value, err := node.Operand.Eval(locals)
+	if err != nil {
+		return 0, err
+	}
+	if node.Operator == ARITHMETIC_SUBTRACT {
+		return -value, nil
+	}
+	return value, nil
+}
+
+// ————————————————————————————————
+
+// Comparisons evaluate to 1 when true and 0 when false
+type ArithmeticBinary struct {
+	Left     ArithmeticNode
+	Operator ArithmeticOperator
+	Right    ArithmeticNode
+}
+
+func (node *ArithmeticBinary) String() string {
+	return node.Left.String() + " " +
+		string(node.Operator) + " " +
+		node.Right.String()
+}
+
+func (node *ArithmeticBinary) Eval(locals Locals) (int64, error) {
+	left, err := node.Left.Eval(locals)
+	if err != nil {
+		return 0, err
+	}
+	right, err := node.Right.Eval(locals)
+	if err != nil {
+		return 0, err
+	}
+
+	switch node.Operator {
+	case ARITHMETIC_ADD:
+		return left + right, nil
+	case ARITHMETIC_SUBTRACT:
+		return left - right, nil
+	case ARITHMETIC_MULTIPLY:
+		return left * right, nil
+	case ARITHMETIC_DIVIDE, ARITHMETIC_MODULO:
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero in %q", node.String())
+		}
+		if node.Operator == ARITHMETIC_DIVIDE {
+			return left / right, nil
+		}
+		return left % right, nil
+	case ARITHMETIC_LESS:
+		return boolToInt(left < right), nil
+	case ARITHMETIC_LESS_EQ:
+		return boolToInt(left <= right), nil
+	case ARITHMETIC_GREATER:
+		return boolToInt(left > right), nil
+	case ARITHMETIC_GREATER_EQ:
+		return boolToInt(left >= right), nil
+	case ARITHMETIC_EQUAL:
+		return boolToInt(left == right), nil
+	case ARITHMETIC_NOT_EQUAL:
+		return boolToInt(left != right), nil
+	}
+
+	return 0, fmt.Errorf("unknown arithmetic operator %q", node.Operator)
+}
+
+func boolToInt(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
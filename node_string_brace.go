@@ -0,0 +1,112 @@
+package libparser
+
+import (
+	"strings"
+)
+
+// An unquoted '{a,b}' that expands into a separate word for each alternative
+type BraceStringSegment struct {
+	Alternatives []SegmentedString
+}
+
+func (segment *BraceStringSegment) Segment() string {
+	parts := make([]string, len(segment.Alternatives))
+	for i, alternative := range segment.Alternatives {
+		parts[i] = alternative.String()
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// Returns the alternatives as written, use [NodeString.EvalWords] to expand them
+func (segment *BraceStringSegment) Eval(locals Locals, options *EvalOptions) (string, error) {
+	parts := make([]string, len(segment.Alternatives))
+	for i, alternative := range segment.Alternatives {
+		var builder strings.Builder
+		for _, part := range alternative {
+			value, err := part.Eval(locals, options)
+			if err != nil {
+				return "", err
+			}
+			builder.WriteString(value)
+		}
+		parts[i] = builder.String()
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+// ————————————————————————————————
+
+// A single word in the middle of being expanded
+type expandingWord struct {
+	// Glob characters that don't come from a [GlobStringSegment] are escaped
+	pattern  string
+	literal  string
+	has_glob bool
+}
+
+func (word expandingWord) append(part string, is_glob bool) expandingWord {
+	word.literal += part
+	if is_glob {
+		word.pattern += part
+		word.has_glob = true
+	} else {
+		word.pattern += escapeGlob(part)
+	}
+	return word
+}
+
+// Appends the segments to every word.
+// Each brace expansion and list splat multiplies the words by the number of its values.
+func appendWordSegments(
+	words []expandingWord,
+	segments SegmentedString,
+	locals Locals,
+	options *EvalOptions,
+) ([]expandingWord, error) {
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+
+		case *BraceStringSegment:
+			var out []expandingWord
+			for _, prefix := range words {
+				for _, alternative := range segment.Alternatives {
+					expanded, err := appendWordSegments([]expandingWord{prefix}, alternative, locals, options)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, expanded...)
+				}
+			}
+			words = out
+			continue
+
+		case *VariableStringSegment:
+			if !segment.IsSplat {
+				break
+			}
+			values, err := segment.EvalList(locals, options)
+			if err != nil {
+				return nil, err
+			}
+			var out []expandingWord
+			for _, prefix := range words {
+				for _, value := range values {
+					out = append(out, prefix.append(value, false))
+				}
+			}
+			words = out
+			continue
+		}
+
+		part, err := segment.Eval(locals, options)
+		if err != nil {
+			return nil, err
+		}
+		_, is_glob := segment.(*GlobStringSegment)
+
+		for i := range words {
+			words[i] = words[i].append(part, is_glob)
+		}
+	}
+	return words, nil
+}
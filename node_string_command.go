@@ -0,0 +1,44 @@
+package libparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Executes the statement of a command substitution and returns its standard output.
+type CommandRunner interface {
+	RunCommand(node Node, locals Locals) (string, error)
+}
+
+// ————————————————————————————————
+
+type CommandStringSegment struct {
+	Node Node
+}
+
+func (segment *CommandStringSegment) Segment() string {
+	command := segment.Node.String()
+	// '$((' would start an arithmetic expansion
+	if strings.HasPrefix(command, "(") {
+		command = " " + command + " "
+	}
+	return fmt.Sprintf("$(%s)", command)
+}
+
+// Runs the statement with [EvalOptions.Runner]
+func (segment *CommandStringSegment) Eval(locals Locals, options *EvalOptions) (string, error) {
+	if options == nil || options.Runner == nil {
+		return "", fmt.Errorf(
+			"cannot evaluate %q, no command runner has been set",
+			segment.Segment(),
+		)
+	}
+
+	output, err := options.Runner.RunCommand(segment.Node, locals)
+	if err != nil {
+		return "", err
+	}
+
+	// Trailing new lines are removed the same way a shell does it
+	return strings.TrimRight(output, "\n"), nil
+}
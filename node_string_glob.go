@@ -0,0 +1,200 @@
+package libparser
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+type GlobNoMatchPolicy uint8
+
+const (
+	// Keep the pattern as a literal word, like POSIX shells do
+	GLOB_KEEP GlobNoMatchPolicy = iota
+	// Remove the word entirely
+	GLOB_DROP
+	// Fail the evaluation
+	GLOB_ERROR
+)
+
+// ————————————————————————————————
+
+// An unquoted '*', '**', '?' or '[...]' pattern
+type GlobStringSegment struct {
+	Pattern string
+}
+
+func (segment *GlobStringSegment) Segment() string {
+	return segment.Pattern
+}
+
+// Returns the pattern itself, use [NodeString.EvalWords] to expand it
+func (segment *GlobStringSegment) Eval(_ Locals, _ *EvalOptions) (string, error) {
+	return segment.Pattern, nil
+}
+
+// ————————————————————————————————
+
+func expandGlob(pattern, literal string, options *EvalOptions) ([]string, error) {
+	if options == nil {
+		options = &EvalOptions{}
+	}
+
+	parts := strings.Split(pattern, "/")
+
+	// Leading directories without patterns don't need to be walked
+	i := 0
+	for i < len(parts)-1 && !hasGlobMeta(parts[i]) {
+		i++
+	}
+	base := unescapeGlob(strings.Join(parts[:i], "/"))
+	if i > 0 && base == "" {
+		base = "/"
+	}
+
+	var fsys fs.FS
+	if options.GlobFS == nil {
+		fsys = os.DirFS(cmp.Or(base, "."))
+	} else {
+		sub, err := fs.Sub(options.GlobFS, path.Clean(cmp.Or(strings.TrimPrefix(base, "/"), ".")))
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+
+	matches, err := walkGlob(fsys, ".", base, parts[i:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		switch options.GlobNoMatch {
+		case GLOB_DROP:
+			return []string{}, nil
+		case GLOB_ERROR:
+			return nil, fmt.Errorf("no files match the pattern %q", literal)
+		}
+		return []string{literal}, nil
+	}
+
+	slices.Sort(matches)
+	return slices.Compact(matches), nil
+}
+
+// Matches the remaining parts of a pattern inside of dir.
+// display is the same directory, but spelled the way it was written in the pattern.
+func walkGlob(fsys fs.FS, dir, display string, parts []string) ([]string, error) {
+	if len(parts) == 0 {
+		return []string{display}, nil
+	}
+	part, rest := parts[0], parts[1:]
+
+	if !hasGlobMeta(part) {
+		name := unescapeGlob(part)
+		if _, err := fs.Stat(fsys, path.Join(dir, name)); err != nil {
+			return nil, nil
+		}
+		return walkGlob(fsys, path.Join(dir, name), joinGlobPath(display, name), rest)
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, nil
+	}
+
+	var out []string
+
+	// '**' matches zero or more directories
+	if part == "**" {
+		matches, err := walkGlob(fsys, dir, display, rest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, matches...)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(part, ".") {
+			continue
+		}
+
+		if part == "**" {
+			if !entry.IsDir() {
+				continue
+			}
+			matches, err := walkGlob(fsys, path.Join(dir, name), joinGlobPath(display, name), parts)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, matches...)
+			continue
+		}
+
+		ok, err := path.Match(part, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q", part)
+		}
+		if !ok || (len(rest) != 0 && !entry.IsDir()) {
+			continue
+		}
+
+		matches, err := walkGlob(fsys, path.Join(dir, name), joinGlobPath(display, name), rest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, matches...)
+	}
+
+	return out, nil
+}
+
+func joinGlobPath(dir, name string) string {
+	switch dir {
+	case "":
+		return name
+	case "/":
+		return "/" + name
+	}
+	return dir + "/" + name
+}
+
+func hasGlobMeta(pattern string) bool {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
+
+func escapeGlob(value string) string {
+	var builder strings.Builder
+	for _, char := range value {
+		switch char {
+		case '*', '?', '[', ']', '\\':
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+func unescapeGlob(pattern string) string {
+	var builder strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+		}
+		builder.WriteByte(pattern[i])
+	}
+	return builder.String()
+}
@@ -77,7 +77,9 @@ var AllModifiers = []ModifierName{
 type StringModifier struct {
 	Name ModifierName
 	Args []*NodeString
-	Call func(Locals, string) string
+	Call func(Locals, *EvalOptions, string) string
+	// Used instead of calling [StringModifier.Call] on each element of a list, nil if not supported
+	CallList func(Locals, *EvalOptions, []string) string
 }
 
 func (modifier StringModifier) String() string {
@@ -125,7 +127,7 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 	switch mod.Name {
 
 	case MOD_NOT:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			switch in {
 			case boolToString(true), "true", "TRUE":
 				return boolToString(false)
@@ -135,39 +137,39 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_TO_LOWER:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strings.ToLower(in)
 		}
 
 	case MOD_TO_UPPER:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strings.ToUpper(in)
 		}
 
 	case MOD_TO_SNAKE:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strcase.ToSnake(in)
 		}
 
 	case MOD_TO_KEBAB:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strcase.ToKebab(in)
 		}
 
 	case MOD_TO_CAMEL:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strcase.ToLowerCamel(in)
 		}
 
 	case MOD_TO_PASCAL:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strcase.ToCamel(in)
 		}
 
 	case MOD_TO_DELIMITED:
 		if len(mod.Args) > 0 && len(mod.Args[0].Segments) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				str, _ := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				str, _ := mod.Args[0].Eval(locals, options)
 				if len(str) == 0 {
 					return in
 				}
@@ -176,18 +178,18 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_DOES_EXIST:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			_, err := os.Stat(in)
 			return boolToString(!os.IsNotExist(err))
 		}
 
 	case MOD_IS_EMPTY:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return boolToString(len(in) == 0)
 		}
 
 	case MOD_IS_FILE:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			stat, err := os.Stat(in)
 			if os.IsNotExist(err) {
 				return boolToString(false)
@@ -196,7 +198,7 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_IS_DIR:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			stat, err := os.Stat(in)
 			if os.IsNotExist(err) {
 				return boolToString(false)
@@ -205,7 +207,7 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_IS_SYMLINK:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			stat, err := os.Lstat(in)
 			if os.IsNotExist(err) {
 				return boolToString(false)
@@ -214,19 +216,22 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_LENGTH:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
+			return fmt.Sprint(len(in))
+		}
+		mod.CallList = func(_ Locals, _ *EvalOptions, in []string) string {
 			return fmt.Sprint(len(in))
 		}
 
 	case MOD_QUOTED:
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return fmt.Sprintf("%q", in)
 		}
 
 	case MOD_TRIM:
-		mod.Call = func(locals Locals, in string) string {
+		mod.Call = func(locals Locals, options *EvalOptions, in string) string {
 			for _, arg := range mod.Args {
-				value, err := arg.Eval(locals)
+				value, err := arg.Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -236,9 +241,9 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_TRIM_PREFIX:
-		mod.Call = func(locals Locals, in string) string {
+		mod.Call = func(locals Locals, options *EvalOptions, in string) string {
 			for _, arg := range mod.Args {
-				value, err := arg.Eval(locals)
+				value, err := arg.Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -248,9 +253,9 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 		}
 
 	case MOD_TRIM_SUFFIX:
-		mod.Call = func(locals Locals, in string) string {
+		mod.Call = func(locals Locals, options *EvalOptions, in string) string {
 			for _, arg := range mod.Args {
-				value, err := arg.Eval(locals)
+				value, err := arg.Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -262,8 +267,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 	case MOD_PAD:
 		switch len(mod.Args) {
 		case 1:
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -274,12 +279,12 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 				return padString(in, number, number)
 			}
 		case 2:
-			mod.Call = func(locals Locals, in string) string {
-				value_1, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value_1, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
-				value_2, err := mod.Args[1].Eval(locals)
+				value_2, err := mod.Args[1].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -297,8 +302,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_PAD_LEFT:
 		if len(mod.Args) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -312,8 +317,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_PAD_RIGHT:
 		if len(mod.Args) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -327,8 +332,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_HAS_PREFIX:
 		if len(mod.Args) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -338,8 +343,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_HAS_SUFFIX:
 		if len(mod.Args) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -349,12 +354,12 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_SLICE:
 		if len(mod.Args) > 1 {
-			mod.Call = func(locals Locals, in string) string {
-				value_1, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value_1, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return in
 				}
-				value_2, err := mod.Args[1].Eval(locals)
+				value_2, err := mod.Args[1].Eval(locals, options)
 				if err != nil {
 					return in
 				}
@@ -372,7 +377,7 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_REVERSE:
 		// https://stackoverflow.com/a/10030772
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			runes := []rune(in)
 			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
 				runes[i], runes[j] = runes[j], runes[i]
@@ -382,7 +387,7 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_INVERT:
 		// https://stackoverflow.com/a/38234154
-		mod.Call = func(_ Locals, in string) string {
+		mod.Call = func(_ Locals, _ *EvalOptions, in string) string {
 			return strings.Map(func(char rune) rune {
 				switch {
 				case unicode.IsLower(char):
@@ -396,8 +401,8 @@ func GetModifier(name ModifierName, args []*NodeString) (StringModifier, error)
 
 	case MOD_CONTAINS:
 		if len(mod.Args) > 0 {
-			mod.Call = func(locals Locals, in string) string {
-				value, err := mod.Args[0].Eval(locals)
+			mod.Call = func(locals Locals, options *EvalOptions, in string) string {
+				value, err := mod.Args[0].Eval(locals, options)
 				if err != nil {
 					return boolToString(false)
 				}
@@ -0,0 +1,46 @@
+package libparser
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+)
+
+// Returns the home directory of a user, or of the current user if the name is empty.
+type HomeDirLookup func(name string) (string, error)
+
+func lookupHomeDir(name string) (string, error) {
+	if name == "" {
+		return os.UserHomeDir()
+	}
+	account, err := user.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return account.HomeDir, nil
+}
+
+// ————————————————————————————————
+
+// An unquoted '~' or '~user' at the start of a word
+type TildeStringSegment struct {
+	// Empty for the current user
+	User string
+}
+
+func (segment *TildeStringSegment) Segment() string {
+	return "~" + segment.User
+}
+
+func (segment *TildeStringSegment) Eval(_ Locals, options *EvalOptions) (string, error) {
+	lookup := lookupHomeDir
+	if options != nil && options.LookupHomeDir != nil {
+		lookup = options.LookupHomeDir
+	}
+
+	dir, err := lookup(segment.User)
+	if err != nil {
+		return "", fmt.Errorf("cannot expand %q: %w", segment.Segment(), err)
+	}
+	return dir, nil
+}
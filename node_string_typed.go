@@ -0,0 +1,98 @@
+package libparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LiteralKind string
+
+const (
+	LITERAL_STRING   LiteralKind = "string"
+	LITERAL_INTEGER  LiteralKind = "integer"
+	LITERAL_FLOAT    LiteralKind = "float"
+	LITERAL_BOOLEAN  LiteralKind = "boolean"
+	LITERAL_DURATION LiteralKind = "duration"
+)
+
+// Classifies an unquoted word, e.g. '3', '0.5', 'true' or '30s'.
+// Quoted or escaped words and words with expansions are always [LITERAL_STRING].
+func (node *NodeString) Kind() LiteralKind {
+	contents, ok := node.bareWord()
+	if !ok {
+		return LITERAL_STRING
+	}
+
+	if _, err := strconv.ParseInt(contents, 10, 64); err == nil {
+		return LITERAL_INTEGER
+	}
+	// ParseFloat also accepts 'inf', 'nan' and hexadecimal notation, which are words here
+	if strings.Trim(contents, "0123456789.eE+-") == "" {
+		if _, err := strconv.ParseFloat(contents, 64); err == nil {
+			return LITERAL_FLOAT
+		}
+	}
+	if contents == "true" || contents == "false" {
+		return LITERAL_BOOLEAN
+	}
+	if _, err := time.ParseDuration(contents); err == nil {
+		return LITERAL_DURATION
+	}
+	return LITERAL_STRING
+}
+
+func (node *NodeString) Int() (int64, error) {
+	contents, err := node.expectKind(LITERAL_INTEGER)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(contents, 10, 64)
+}
+
+// Integers are accepted as well
+func (node *NodeString) Float() (float64, error) {
+	contents, err := node.expectKind(LITERAL_FLOAT, LITERAL_INTEGER)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(contents, 64)
+}
+
+func (node *NodeString) Bool() (bool, error) {
+	contents, err := node.expectKind(LITERAL_BOOLEAN)
+	if err != nil {
+		return false, err
+	}
+	return contents == "true", nil
+}
+
+func (node *NodeString) Duration() (time.Duration, error) {
+	contents, err := node.expectKind(LITERAL_DURATION)
+	if err != nil {
+		return 0, err
+	}
+	return time.ParseDuration(contents)
+}
+
+// Returns the contents of a word that was written without quotes, escapes or expansions.
+// Strings that weren't parsed have no [NodeString.Raw], so they are never bare words.
+func (node *NodeString) bareWord() (string, bool) {
+	contents, ok := node.Literal()
+	if !ok || contents == "" || node.Raw != contents {
+		return "", false
+	}
+	return contents, true
+}
+
+func (node *NodeString) expectKind(kinds ...LiteralKind) (string, error) {
+	kind := node.Kind()
+	for _, expected := range kinds {
+		if kind == expected {
+			contents, _ := node.bareWord()
+			return contents, nil
+		}
+	}
+	return "", fmt.Errorf("expected %s, but got %s %q", kinds[0], kind, node.Segments.String())
+}
@@ -3,21 +3,83 @@ package libparser
 import (
 	"bufio"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	liberrors "github.com/tomefile/lib-errors"
 	"github.com/tomefile/lib-parser/readers"
 )
 
-type Locals map[string]string
+// The value of a variable, either a string or a list of strings
+type Value struct {
+	values  []string
+	is_list bool
+}
+
+func StringValue(value string) Value {
+	return Value{values: []string{value}}
+}
+
+func ListValue(values ...string) Value {
+	return Value{values: slices.Clone(values), is_list: true}
+}
+
+func (value Value) IsList() bool {
+	return value.is_list
+}
+
+// Returns the value as a list, a string is a list of a single element
+func (value Value) List() []string {
+	if value.values == nil && !value.is_list {
+		return []string{""}
+	}
+	return value.values
+}
+
+// Returns the value as a string, elements of a list are joined with spaces
+func (value Value) String() string {
+	return strings.Join(value.values, " ")
+}
+
+// Variables in scope
+type Locals map[string]Value
+
+// Returns the value of a variable as a list, a string is a list of a single element
+func (locals Locals) List(name string) ([]string, bool) {
+	value, exists := locals[name]
+	if !exists {
+		return nil, false
+	}
+	return value.List(), true
+}
+
+// Returns the value of a variable as a string, elements of a list are joined with spaces
+func (locals Locals) Get(name string) (string, bool) {
+	value, exists := locals[name]
+	return value.String(), exists
+}
 
 type Parser struct {
-	Parent    *Parser
-	File      File
-	Result    *NodeRoot
-	Hooks     []Hook
-	reader    *readers.Reader
-	container *NodeChildren
+	Parent *Parser
+	File   File
+	Result *NodeRoot
+	Hooks  []Hook
+	// Restricts the names of variables, directives and tomes to ASCII letters and digits
+	StrictASCII bool
+	reader      *readers.Reader
+	container   *NodeChildren
+	// Heredocs whose bodies haven't been read yet
+	heredocs []*NodeHeredoc
+	// Nodes that are written once the bodies of the pending heredocs have been read
+	awaiting []pendingWrite
+	// Trailing comment of the statement that is being read
+	comment *NodeComment
+	// Whether words stop at the comparison operators of a condition, e.g. '$a==b'
+	is_in_condition bool
+	// The last ':if' chain, which is written once a statement other than ':elif' or ':else' follows
+	conditional *pendingConditional
 }
 
 func New(file File) *Parser {
@@ -48,7 +110,10 @@ func (parser *Parser) Run() *liberrors.DetailedError {
 			continue
 
 		case EOF:
-			return nil
+			if derr := parser.failPendingHeredocs(); derr != nil {
+				return derr
+			}
+			return parser.flushConditional()
 
 		case UNEXPECTED_EOF:
 			return parser.failReading(derr)
@@ -56,6 +121,9 @@ func (parser *Parser) Run() *liberrors.DetailedError {
 		case EOB:
 			return parser.failSyntaxHere("unexpected '}' with no matching '{' pair")
 
+		case EOS:
+			return parser.failSyntaxHere("unexpected ')' with no matching '(' pair")
+
 		default:
 			return derr
 		}
@@ -72,6 +140,9 @@ func (parser *Parser) next() *liberrors.DetailedError {
 
 	if readers.WhitespaceCharset(char) || char == ';' {
 		if char == '\n' {
+			if len(parser.heredocs) != 0 {
+				return parser.readHeredocBodies()
+			}
 			return parser.write(&NodeWhitespace{NodeContext: parser.makeContext(start_offset)})
 		}
 		return nil
@@ -79,25 +150,32 @@ func (parser *Parser) next() *liberrors.DetailedError {
 
 	switch char {
 
-	case '}':
-		char, _ := parser.reader.Read()
-		if char != '\n' {
+	case '}', ')':
+		end := EOB
+		if char == ')' {
+			end = EOS
+		}
+		if char, err := parser.reader.Read(); err == nil && char != '\n' {
 			parser.reader.Unread()
 		}
-		return EOB
+		return end
 
 	case '#':
 		comment, err := parser.reader.ReadDelimited(true, '\n')
 		if err != nil {
 			return parser.failReading(err)
 		}
-		return parser.write(&NodeComment{
+		derr := parser.write(&NodeComment{
 			Contents:    comment,
 			NodeContext: parser.makeContext(start_offset),
 		})
+		if derr == nil && len(parser.heredocs) != 0 && parser.reader.Last() == '\n' {
+			return parser.readHeredocBodies()
+		}
+		return derr
 
 	case ':':
-		name, err := parser.reader.ReadSequence(readers.NameCharset)
+		name, err := parser.reader.ReadSequence(parser.charset(readers.NameCharset))
 		if err != nil {
 			return parser.failReading(err)
 		}
@@ -105,322 +183,1584 @@ func (parser *Parser) next() *liberrors.DetailedError {
 			return parser.failSyntaxHere("missing a directive name after ':'")
 		}
 
-		args, derr := parser.readArgs()
+		var args NodeArgs
+		var derr *liberrors.DetailedError
+		if slices.Contains(EXPRESSION_DIRECTIVES, name) {
+			var condition *NodeExpr
+			condition, derr = parser.readExpression()
+			args = NodeArgs{condition}
+		} else {
+			args, derr = parser.readArgs()
+		}
 		if derr != nil && derr != EOF {
 			return derr
 		}
+		comment := parser.takeComment()
 
 		children, err := parser.readChildren()
 		if err != nil && err != io.EOF {
 			return parser.failReading(err)
 		}
 
-		return parser.write(&NodeDirective{
+		directive := &NodeDirective{
 			Name:         name,
 			NodeArgs:     args,
 			NodeChildren: children,
 			NodeContext:  parser.makeContext(start_offset),
-		})
+		}
+
+		switch name {
+		case "if":
+			if derr := parser.flushConditional(); derr != nil {
+				return derr
+			}
+			parser.conditional = &pendingConditional{
+				node: &NodeConditional{
+					Branches:    []*NodeDirective{directive},
+					NodeContext: directive.NodeContext,
+				},
+			}
+			if comment != nil {
+				return parser.write(comment)
+			}
+			return nil
+		case "elif", "else":
+			if derr := parser.linkBranch(directive); derr != nil {
+				return derr
+			}
+			if comment != nil {
+				return parser.write(comment)
+			}
+			return nil
+		}
+
+		return parser.writeStatement(directive, comment)
 	}
 
-	if !readers.FilenameCharset(char) && !readers.QuotesCharset(char) {
+	parser.reader.Unread()
+	if !parser.charset(readers.FilenameCharset)(char) && !readers.QuotesCharset(char) &&
+		char != '(' && char != '{' && !parser.isAtRedirection() {
+		parser.reader.Read()
 		return parser.failSyntaxHere(
 			"unexpected %q at the start of a statement. If it belongs to the statement above, add a '\\' to the end of the previous line.",
 			char,
 		)
 	}
 
-	parser.reader.Unread()
-	node, derr := parser.readStatement()
+	node, derr := parser.readChain()
 	if derr != nil {
 		return derr
 	}
 
-	peek, _ := parser.reader.Peek()
-	if peek == '>' || peek == '<' {
-		redirection, derr := parser.readRedirection()
-		if derr != nil {
+	if parser.isAtBackground() {
+		parser.reader.Read()
+		node = &NodeBackground{
+			Node:        node,
+			NodeContext: parser.makeContext(start_offset),
+		}
+		if derr := parser.readLineEnd(); derr != nil {
 			return derr
 		}
-		redirection.Source = node
-		redirection.NodeContext = parser.makeContext(start_offset)
-		return parser.write(redirection)
 	}
 
-	return parser.write(node)
-}
-
-func (parser *Parser) readRedirection() (*NodeRedirect, *liberrors.DetailedError) {
-	out := &NodeRedirect{}
-
-	for {
-		char, err := parser.reader.Read()
-		if err != nil {
-			return out, parser.failReading(err)
-		}
-
-		switch char {
-		case '\n':
-			return out, nil
-
-		case '<':
-			filename, derr := parser.readFilename()
-			if derr != nil {
-				return out, derr
-			}
-			out.Stdin = filename
-
-		case '>':
-			char, err := parser.reader.Peek()
-			if err != nil {
-				return nil, parser.failReading(err)
-			}
-			switch char {
-			case '>':
-				parser.reader.Read()
-				filename, derr := parser.readFilename()
-				if derr != nil {
-					return out, derr
-				}
-				out.Stderr = filename
-			default:
-				filename, derr := parser.readFilename()
-				if derr != nil {
-					return out, derr
-				}
-				out.Stdout = filename
-			}
+	if len(parser.heredocs) != 0 && parser.reader.Last() == '\n' {
+		if derr := parser.readHeredocBodies(); derr != nil {
+			return derr
 		}
 	}
-}
-
-func (parser *Parser) readFilename() (*NodeString, *liberrors.DetailedError) {
-	// TODO: Add string parsing
-	char, err := parser.reader.Read()
-	if err != nil {
-		return nil, parser.failReading(err)
-	}
-
-	switch char {
-
-	case ' ':
-		return parser.readFilename()
-
-	case '\'', '"', '`':
-		contents, err := parser.reader.ReadInsideQuotes(char)
-		if err != nil {
-			return nil, parser.failReading(err)
-		}
-		return NewSimpleNodeString(contents), nil
 
-	default:
-		parser.reader.Unread()
-		filename, err := parser.reader.ReadSequence(readers.FilenameCharset)
-		if err != nil {
-			return nil, parser.failReading(err)
-		}
-		return NewSimpleNodeString(filename), nil
-	}
+	return parser.writeStatement(node, parser.takeComment())
 }
 
-func (parser *Parser) readStatement() (Node, *liberrors.DetailedError) {
+// Reads pipelines joined with '&&' and '||', which have the same precedence and are left-associative.
+func (parser *Parser) readChain() (Node, *liberrors.DetailedError) {
 	start_offset := parser.reader.Offset
 
-	string_name, err := parser.readFilename()
-	if err != nil {
-		return nil, parser.failReading(err)
-	}
-	name := string_name.String()
-
-	args, derr := parser.readArgs()
-	if derr != nil && derr != EOF {
+	node, derr := parser.readPipeline()
+	if derr != nil {
 		return nil, derr
 	}
 
-	var node Node
-	if strings.HasSuffix(name, "!") {
-		node = &NodeCall{
-			Macro:       name[:len(name)-1],
-			NodeArgs:    args,
-			NodeContext: parser.makeContext(start_offset),
+	for !parser.hasStatementEnded() {
+		operator, _ := parser.reader.PeekString(2)
+		if operator != string(CHAIN_AND) && operator != string(CHAIN_OR) {
+			return node, nil
 		}
-	} else {
-		node = &NodeExec{
-			Name:        name,
-			NodeArgs:    args,
+		parser.reader.Read()
+		parser.reader.Read()
+		parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+		right, derr := parser.readPipeline()
+		if derr != nil {
+			return nil, derr
+		}
+
+		node = &NodeChain{
+			Left:        node,
+			Operator:    ChainOperator(operator),
+			Right:       right,
 			NodeContext: parser.makeContext(start_offset),
 		}
 	}
 
-	char, _ := parser.reader.Peek()
+	return node, nil
+}
 
-	switch char {
+// Reads statements joined with '|', the whole pipeline is negated by a leading '!'
+func (parser *Parser) readPipeline() (Node, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
 
-	case '|':
+	if parser.isAtNegation() {
 		parser.reader.Read()
-		parser.reader.ReadSequence(readers.WhitespaceCharset)
+		parser.reader.ReadSequence(readers.BlankCharset)
 
-		target, derr := parser.readStatement()
+		node, derr := parser.readPipeline()
 		if derr != nil {
 			return nil, derr
 		}
-		return &NodePipe{
-			Source:      node,
-			Dest:        target,
+		return &NodeNot{
+			Node:        node,
 			NodeContext: parser.makeContext(start_offset),
 		}, nil
+	}
 
-	case ')':
-		parser.reader.Read()
+	node, derr := parser.readStatement()
+	if derr != nil {
+		return nil, derr
 	}
 
-	return node, nil
+	if parser.hasStatementEnded() {
+		return node, nil
+	}
+
+	peek, _ := parser.reader.PeekString(2)
+	if !strings.HasPrefix(peek, "|") || peek == string(CHAIN_OR) {
+		return node, nil
+	}
+	parser.reader.Read()
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	target, derr := parser.readPipeline()
+	if derr != nil {
+		return nil, derr
+	}
+	return &NodePipe{
+		Source:      node,
+		Dest:        target,
+		NodeContext: parser.makeContext(start_offset),
+	}, nil
 }
 
-func (parser *Parser) readArgs() (NodeArgs, *liberrors.DetailedError) {
-	var out = NodeArgs{}
-	for {
-		arg, derr := parser.readArg()
-		if arg != nil {
-			out = append(out, arg)
+// Reads a single stage of a pipeline, i.e. a command or a group, along with its redirections.
+// Redirections can appear before, after or in between the arguments, e.g. '<input.txt sort -r'.
+func (parser *Parser) readStatement() (Node, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+	redirect := &NodeRedirect{Operations: []RedirectOperation{}}
+
+	if parser.isAtRedirection() {
+		if derr := parser.readRedirections(redirect); derr != nil {
+			return nil, derr
 		}
-		if derr != nil {
-			if derr == EOA {
-				return out, nil
-			}
-			return out, derr
+	}
+
+	var node Node
+	var derr *liberrors.DetailedError
+	if parser.isAtGroup() {
+		node, derr = parser.readGroup()
+		if derr == nil && !parser.hasStatementEnded() && parser.isAtRedirection() {
+			derr = parser.readRedirections(redirect)
 		}
+	} else {
+		node, derr = parser.readCommand(redirect)
+	}
+	if derr != nil {
+		return nil, derr
 	}
-}
 
-func (parser *Parser) readArg() (Node, *liberrors.DetailedError) {
-	var start_offset = parser.reader.Offset
-	var out = SegmentedString{}
-	var current_segment strings.Builder
+	if len(redirect.Operations) == 0 {
+		return node, nil
+	}
+	redirect.Source = node
+	redirect.NodeContext = parser.makeContext(start_offset)
+	return redirect, nil
+}
 
+// Reads consecutive redirections into out along with the blanks around them
+func (parser *Parser) readRedirections(out *NodeRedirect) *liberrors.DetailedError {
 	for {
 		char, err := parser.reader.Read()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			return nil, parser.failReading(err)
+			return parser.failReading(err)
 		}
 
-		if readers.ArglistTeminatingCharset(char) {
-			if current_segment.Len() != 0 {
-				out = append(out, &LiteralStringSegment{Contents: current_segment.String()})
-			}
-
-			if parser.escaped(char, '\n') {
-				return &NodeWhitespace{IsLineBreak: true}, nil
-			}
-
-			if char == ' ' || char == '\t' || parser.escaped(char, '\n') {
-				if len(out) == 0 {
-					return nil, nil
-				}
-				return &NodeString{
-					Segments:    out,
-					NodeContext: parser.makeContext(start_offset),
-				}, nil
-			}
+		switch char {
+		case '\n':
+			return nil
 
-			if current_segment.Len() == 0 && len(out) == 0 {
-				if char != '\n' {
-					parser.reader.Unread()
-				}
-				return nil, EOA
-			}
+		case ' ', '\t':
+			continue
 
-			var derr *liberrors.DetailedError
-			if char == '\n' || char == ';' || char == ')' {
-				derr = EOA
+		case '#':
+			if readers.WhitespaceCharset(parser.reader.Previous()) {
+				return parser.readTrailingComment()
 			}
+		}
 
-			return &NodeString{
-				Segments:    out,
-				NodeContext: parser.makeContext(start_offset),
-			}, derr
+		parser.reader.Unread()
+		if !parser.isAtRedirection() {
+			return nil
 		}
 
-		if char != '$' {
-			if char == '\'' || char == '"' || char == '`' {
-				contents, err := parser.reader.ReadInsideQuotes(char)
-				if err != nil {
-					return nil, parser.failReading(err)
-				}
-				literal := &NodeLiteral{
-					Contents:    contents,
-					NodeContext: parser.makeContext(start_offset),
-				}
-				if char == '\'' {
-					return literal, nil
-				}
-				return literal.ToStringNode(), nil
-			}
-			// was the previous character '\\'
-			if parser.escaped(0, 0) {
-				current_segment.WriteRune('\\')
-			}
-			if char != '\\' {
-				current_segment.WriteRune(char)
-			}
-			continue
+		operations, derr := parser.readRedirectOperation()
+		if derr != nil {
+			return derr
 		}
+		out.Operations = append(out.Operations, operations...)
 
-		if current_segment.Len() != 0 {
-			out = append(out, &LiteralStringSegment{Contents: current_segment.String()})
-			current_segment.Reset()
+		if parser.hasStatementEnded() {
+			return nil
 		}
+	}
+}
 
-		char_after_dollar, err := parser.reader.Read()
-		if err != nil {
-			return nil, parser.failReading(err)
+// Reads a single redirection such as '<file', '2>>file', '2>&1' or '&>file'.
+// The last one results in two operations.
+func (parser *Parser) readRedirectOperation() ([]RedirectOperation, *liberrors.DetailedError) {
+	digits, err := parser.reader.ReadSequence(isDigit)
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	char, err := parser.reader.Read()
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	var operation RedirectOperation
+
+	switch char {
+
+	case '&':
+		parser.reader.Read() // '>'
+		operation.Mode = REDIRECT_WRITE
+		if peek, _ := parser.reader.Peek(); peek == '>' {
+			parser.reader.Read()
+			operation.Mode = REDIRECT_APPEND
 		}
+		operation.Fd = 1
 
-		if readers.WhitespaceCharset(char_after_dollar) || char_after_dollar == ';' {
-			literal := &NodeLiteral{
-				Contents:    "$",
-				NodeContext: parser.makeContext(start_offset),
-			}
-			return literal.ToStringNode(), nil
+		target, derr := parser.readRedirectTarget(operation.Mode)
+		if derr != nil {
+			return nil, derr
 		}
+		operation.Target = target
 
-		switch char_after_dollar {
+		return []RedirectOperation{
+			operation,
+			{Fd: 2, Mode: REDIRECT_DUPLICATE, TargetFd: 1},
+		}, nil
 
-		case '(':
-			node, derr := parser.readStatement()
-			if derr != nil {
-				return nil, derr
+	case '<':
+		operation.Mode = REDIRECT_READ
+		switch peek, _ := parser.reader.Peek(); peek {
+		case '<':
+			parser.reader.Read()
+			operation.Mode = REDIRECT_HEREDOC
+			if peek, _ := parser.reader.Peek(); peek == '<' {
+				parser.reader.Read()
+				operation.Mode = REDIRECT_HERESTRING
 			}
-			return node, nil
+		case '&':
+			parser.reader.Read()
+			operation.Mode = REDIRECT_DUPLICATE_INPUT
+		}
 
-		case '{':
-			segment, derr := parser.readVariableExpansion()
-			if derr != nil {
-				return nil, derr
-			}
-			out = append(out, segment)
+	case '>':
+		operation.Mode = REDIRECT_WRITE
+		switch peek, _ := parser.reader.Peek(); peek {
+		case '>':
+			parser.reader.Read()
+			operation.Mode = REDIRECT_APPEND
+		case '&':
+			parser.reader.Read()
+			operation.Mode = REDIRECT_DUPLICATE
+		}
+	}
 
-		default:
-			word, err := parser.reader.ReadSequence(readers.NameCharset)
-			if err != nil {
-				return nil, parser.failReading(err)
-			}
-			out = append(out, &VariableStringSegment{
-				Name:       string(char_after_dollar) + word,
-				Modifiers:  []StringModifier{},
-				IsOptional: false,
-			})
+	operation.Fd = operation.Mode.DefaultFd()
+	if len(digits) != 0 {
+		fd, err := strconv.ParseUint(digits, 10, 0)
+		if err != nil {
+			return nil, parser.failSyntaxHere("invalid file descriptor %q", digits)
 		}
+		operation.Fd = uint(fd)
 	}
-}
 
-func (parser *Parser) readChildren() (NodeChildren, error) {
-	out := NodeChildren{}
-	backup := parser.container
-	parser.container = &out
-	defer func() {
-		parser.container = backup
-	}()
+	switch operation.Mode {
+
+	case REDIRECT_HEREDOC:
+		heredoc, derr := parser.readHeredoc()
+		if derr != nil {
+			return nil, derr
+		}
+		parser.heredocs = append(parser.heredocs, heredoc)
+		operation.Target = heredoc
+
+	case REDIRECT_DUPLICATE, REDIRECT_DUPLICATE_INPUT:
+		digits, err := parser.reader.ReadSequence(isDigit)
+		if err != nil && err != io.EOF {
+			return nil, parser.failReading(err)
+		}
+		fd, err := strconv.ParseUint(digits, 10, 0)
+		if err != nil {
+			return nil, parser.failSyntaxHere("missing a file descriptor after '%s'", operation.Mode)
+		}
+		operation.TargetFd = uint(fd)
+
+	default:
+		target, derr := parser.readRedirectTarget(operation.Mode)
+		if derr != nil {
+			return nil, derr
+		}
+		operation.Target = target
+	}
+
+	return []RedirectOperation{operation}, nil
+}
+
+func (parser *Parser) readRedirectTarget(mode RedirectMode) (*NodeString, *liberrors.DetailedError) {
+	parser.reader.ReadSequence(readers.BlankCharset)
+
+	filename, derr := parser.readWord()
+	if derr != nil && derr != EOA && derr != EOF {
+		return nil, derr
+	}
+	if filename == nil {
+		return nil, parser.failSyntaxHere("missing a file name after '%s'", mode)
+	}
+	return filename, nil
+}
+
+// Reads the delimiter of a heredoc after '<<', the body is read at the end of the line.
+func (parser *Parser) readHeredoc() (*NodeHeredoc, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset - 2
+	out := &NodeHeredoc{}
+
+	if peek, _ := parser.reader.Peek(); peek == '-' {
+		parser.reader.Read()
+		out.StripTabs = true
+	}
+
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	char, err := parser.reader.Read()
+	if err == io.EOF {
+		return nil, parser.failSyntaxHere("missing a heredoc delimiter after '<<'")
+	}
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	if readers.QuotesCharset(char) {
+		out.IsLiteral = true
+		out.Delimiter, err = parser.reader.ReadInsideQuotes(char)
+	} else {
+		parser.reader.Unread()
+		out.Delimiter, err = parser.reader.ReadSequence(parser.charset(readers.NameCharset))
+		if err == io.EOF {
+			// The missing body is reported once the statement is over
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	if len(out.Delimiter) == 0 {
+		return nil, parser.failSyntaxHere("missing a heredoc delimiter after '<<'")
+	}
+
+	out.NodeContext = parser.makeContext(start_offset)
+	return out, nil
+}
+
+// Reads the bodies of all pending heredocs in the order they were opened,
+// then writes the nodes that were waiting for them.
+// They start on the line right after the statement.
+func (parser *Parser) readHeredocBodies() *liberrors.DetailedError {
+	for _, heredoc := range parser.heredocs {
+		if derr := parser.readHeredocBody(heredoc); derr != nil {
+			return derr
+		}
+	}
+	parser.heredocs = nil
+	return parser.flushAwaiting()
+}
+
+// Reads the lines of a heredoc until the one that only contains its delimiter
+func (parser *Parser) readHeredocBody(heredoc *NodeHeredoc) *liberrors.DetailedError {
+	start_offset := parser.reader.Offset
+	builder := &segmentBuilder{}
+
+	for {
+		if heredoc.StripTabs {
+			parser.reader.ReadSequence(func(char rune) bool { return char == '\t' })
+		}
+
+		line, err := parser.reader.PeekString(len(heredoc.Delimiter) + 1)
+		if line == heredoc.Delimiter+"\n" || (err == io.EOF && line == heredoc.Delimiter) {
+			for range line {
+				parser.reader.Read()
+			}
+			break
+		}
+
+		if derr := parser.readHeredocLine(heredoc, builder); derr != nil {
+			return derr
+		}
+	}
+
+	heredoc.Body = &NodeString{
+		Segments:    builder.Segments(),
+		NodeContext: parser.makeContext(start_offset),
+	}
+	heredoc.OffsetEnd = parser.reader.Offset
+	return nil
+}
+
+func (parser *Parser) readHeredocLine(heredoc *NodeHeredoc, builder *segmentBuilder) *liberrors.DetailedError {
+	for {
+		char, err := parser.reader.Read()
+		if err == io.EOF {
+			return parser.failSyntaxHere("missing %q at the end of a heredoc", heredoc.Delimiter)
+		}
+		if err != nil {
+			return parser.failReading(err)
+		}
+
+		if heredoc.IsLiteral {
+			builder.WriteRune(char)
+			if char == '\n' {
+				return nil
+			}
+			continue
+		}
+
+		switch char {
+
+		case '\n':
+			builder.WriteRune(char)
+			return nil
+
+		case '\\':
+			char, err = parser.reader.Read()
+			if err != nil {
+				return parser.failReading(err)
+			}
+			switch char {
+			case '$', '`', '\\':
+				builder.WriteRune(char)
+			case '\n':
+				// line continuation
+			default:
+				builder.WriteRune('\\')
+				builder.WriteRune(char)
+			}
+
+		case '$':
+			if derr := parser.readExpansion(builder); derr != nil {
+				return derr
+			}
+
+		default:
+			builder.WriteRune(char)
+		}
+	}
+}
+
+func (parser *Parser) readFilename() (*NodeString, *liberrors.DetailedError) {
+	char, err := parser.reader.Read()
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	switch char {
+
+	case ' ':
+		return parser.readFilename()
+
+	case '\'':
+		contents, err := parser.reader.ReadInsideQuotes(char)
+		if err != nil {
+			return nil, parser.failReading(err)
+		}
+		return NewSimpleNodeString(contents), nil
+
+	case '"', '`':
+		builder := &segmentBuilder{}
+		if derr := parser.readInsideQuotes(char, builder); derr != nil {
+			return nil, derr
+		}
+		return &NodeString{Segments: builder.Segments()}, nil
+
+	default:
+		parser.reader.Unread()
+		filename, err := parser.reader.ReadSequence(parser.charset(readers.FilenameCharset))
+		if err != nil {
+			return nil, parser.failReading(err)
+		}
+		return NewSimpleNodeString(filename), nil
+	}
+}
+
+// Reads '{ ... }' or '( ... )' as a single statement
+func (parser *Parser) readGroup() (*NodeGroup, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+
+	char, err := parser.reader.Read()
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	out := &NodeGroup{
+		IsSubshell:   char == '(',
+		NodeChildren: NodeChildren{},
+	}
+	end, closing := EOB, '}'
+	if out.IsSubshell {
+		end, closing = EOS, ')'
+	}
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	backup, conditional := parser.container, parser.conditional
+	parser.container, parser.conditional = &out.NodeChildren, nil
+	defer func() {
+		parser.container, parser.conditional = backup, conditional
+	}()
+
+	for {
+		derr := parser.next()
+		if derr == nil {
+			continue
+		}
+		if derr == end {
+			break
+		}
+
+		switch derr {
+		case EOF:
+			return nil, parser.failSyntaxHere("missing %q at the end of a group", closing)
+		case EOB, EOS:
+			return nil, parser.failSyntaxHere("expected %q at the end of a group", closing)
+		}
+		return nil, derr
+	}
+	if derr := parser.flushConditional(); derr != nil {
+		return nil, derr
+	}
+
+	out.NodeContext = parser.makeContext(start_offset)
+
+	if parser.hasStatementEnded() {
+		return out, nil
+	}
+	if derr := parser.readLineEnd(); derr != nil {
+		return nil, derr
+	}
+	if peek, _ := parser.reader.Peek(); peek == ';' {
+		parser.reader.Read()
+	}
+	return out, nil
+}
+
+// Reads the condition of a directive up to the end of the line or the '{' of its block
+func (parser *Parser) readExpression() (*NodeExpr, *liberrors.DetailedError) {
+	parser.is_in_condition = true
+	defer func() {
+		parser.is_in_condition = false
+	}()
+	parser.reader.ReadSequence(readers.BlankCharset)
+
+	expression, derr := parser.readExpressionBinary(0)
+	if derr != nil {
+		return nil, derr
+	}
+
+	if !parser.hasStatementEnded() {
+		if derr := parser.readLineEnd(); derr != nil {
+			return nil, derr
+		}
+	}
+	if parser.hasStatementEnded() {
+		return expression, nil
+	}
+
+	switch peek, err := parser.reader.Peek(); {
+	case err != nil, peek == '{', peek == '}', peek == ')', peek == ';':
+		return expression, nil
+	}
+	char, _ := parser.reader.Read()
+	return nil, parser.failSyntaxHere(
+		"unexpected %q after a condition. Join the operands with an operator or quote them",
+		char,
+	)
+}
+
+// Reads the operators of a condition, see [readBinary]
+func (parser *Parser) readExpressionBinary(min_precedence int) (*NodeExpr, *liberrors.DetailedError) {
+	return readBinary(parser, min_precedence, binaryGrammar[ExprOperator, *NodeExpr]{
+		precedence: EXPR_PRECEDENCE,
+		blanks:     readers.BlankCharset,
+		operand:    parser.readExpressionOperand,
+		join: func(left *NodeExpr, operator ExprOperator, right *NodeExpr, start_offset uint) *NodeExpr {
+			return &NodeExpr{
+				Operator:    operator,
+				Left:        left,
+				Right:       right,
+				NodeContext: parser.makeContext(start_offset),
+			}
+		},
+	})
+}
+
+// Reads a string or a number, a negation with '!' or an expression inside of '(...)'
+func (parser *Parser) readExpressionOperand() (*NodeExpr, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+	peek, _ := parser.reader.PeekString(2)
+
+	switch {
+
+	case strings.HasPrefix(peek, "("):
+		parser.reader.Read()
+		parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+		inner, derr := parser.readExpressionBinary(0)
+		if derr != nil {
+			return nil, derr
+		}
+
+		parser.reader.ReadSequence(readers.WhitespaceCharset)
+		if char, _ := parser.reader.Read(); char != ')' {
+			return nil, parser.failSyntaxHere("missing ')' inside of a condition")
+		}
+		inner.IsGrouped = true
+		inner.NodeContext = parser.makeContext(start_offset)
+		return inner, nil
+
+	case strings.HasPrefix(peek, "!") && peek != string(EXPR_NOT_EQUAL):
+		parser.reader.Read()
+		parser.reader.ReadSequence(readers.BlankCharset)
+
+		// '!' applies to a whole comparison, e.g. '! $a == $b'
+		inner, derr := parser.readExpressionBinary(EXPR_PRECEDENCE[EXPR_EQUAL])
+		if derr != nil {
+			return nil, derr
+		}
+		return &NodeExpr{
+			Operator:    EXPR_NOT,
+			Left:        inner,
+			NodeContext: parser.makeContext(start_offset),
+		}, nil
+	}
+
+	operand, derr := parser.readWord()
+	if derr != nil && derr != EOA && derr != EOF {
+		return nil, derr
+	}
+	if operand == nil {
+		return nil, parser.failSyntaxHere("missing an operand inside of a condition")
+	}
+
+	return &NodeExpr{
+		Operand:     operand,
+		NodeContext: parser.makeContext(start_offset),
+	}, nil
+}
+
+// Reads a command along with its environment variables and arguments.
+// Redirections in between the arguments are added to redirect.
+func (parser *Parser) readCommand(redirect *NodeRedirect) (Node, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+
+	var env []*NodeAssignment
+	for parser.isAtAssignment() {
+		assignment, derr := parser.readAssignment()
+		if derr != nil && derr != EOA && derr != EOF {
+			return nil, derr
+		}
+		env = append(env, assignment)
+
+		if derr == EOA || derr == EOF || parser.hasStatementEnded() {
+			return nil, parser.failSyntaxHere("missing a command after %q", assignment.String())
+		}
+		parser.reader.ReadSequence(readers.BlankCharset)
+	}
+
+	name, derr := parser.readWord()
+	if derr != nil && derr != EOA && derr != EOF {
+		return nil, derr
+	}
+	if name == nil {
+		return nil, parser.failSyntaxHere("missing a command")
+	}
+
+	args := NodeArgs{}
+	for {
+		if derr == nil {
+			var more NodeArgs
+			more, derr = parser.readArgs()
+			args = append(args, more...)
+			if derr != nil && derr != EOF {
+				return nil, derr
+			}
+		}
+		if derr == EOF || parser.hasStatementEnded() || !parser.isAtRedirection() {
+			break
+		}
+
+		if derr = parser.readRedirections(redirect); derr != nil {
+			return nil, derr
+		}
+		if parser.hasStatementEnded() {
+			break
+		}
+	}
+
+	var node Node
+	if macro, ok := name.Literal(); ok && strings.HasSuffix(macro, "!") {
+		if len(env) != 0 {
+			return nil, parser.failSyntax(
+				start_offset,
+				"environment variables can't be passed to macro %q",
+				macro,
+			)
+		}
+		node = &NodeCall{
+			Macro:       macro[:len(macro)-1],
+			NodeArgs:    args,
+			NodeContext: parser.makeContext(start_offset),
+		}
+	} else if wait, ok := name.Literal(); ok && wait == "wait" && len(env) == 0 {
+		node = &NodeWait{
+			NodeArgs:    args,
+			NodeContext: parser.makeContext(start_offset),
+		}
+	} else {
+		node = &NodeExec{
+			Env:         env,
+			Name:        name,
+			NodeArgs:    args,
+			NodeContext: parser.makeContext(start_offset),
+		}
+	}
+
+	return node, nil
+}
+
+// Reads an environment variable assignment that prefixes a command, e.g. 'GOOS=linux'
+func (parser *Parser) readAssignment() (*NodeAssignment, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+
+	name, err := parser.reader.ReadSequence(parser.charset(readers.AssignmentCharset))
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+	parser.reader.Read() // '='
+
+	out := &NodeAssignment{
+		Name:  name,
+		Value: NewSimpleNodeString(""),
+	}
+
+	value, derr := parser.readWord()
+	if value != nil {
+		out.Value = value
+	}
+
+	out.NodeContext = parser.makeContext(start_offset)
+	return out, derr
+}
+
+func (parser *Parser) readArgs() (NodeArgs, *liberrors.DetailedError) {
+	var out = NodeArgs{}
+	for {
+		peek, _ := parser.reader.Peek()
+		if peek == '(' {
+			parser.reader.Read()
+			args, derr := parser.readArgList()
+			out = append(out, args...)
+			if derr != nil {
+				return out, derr
+			}
+			continue
+		}
+
+		arg, derr := parser.readArg()
+		if arg != nil {
+			out = append(out, arg)
+		}
+		if derr != nil {
+			if derr == EOA {
+				return out, nil
+			}
+			return out, derr
+		}
+	}
+}
+
+// Skips the rest of the line if it only contains whitespace or a trailing comment
+func (parser *Parser) readLineEnd() *liberrors.DetailedError {
+	parser.reader.ReadSequence(readers.BlankCharset)
+
+	switch peek, _ := parser.reader.Peek(); peek {
+	case '#':
+		parser.reader.Read()
+		return parser.readTrailingComment()
+	case '\n':
+		parser.reader.Read()
+	}
+	return nil
+}
+
+// Reads a comment that follows a statement on the same line, e.g. 'echo hi # note'.
+// It's written right after the statement.
+func (parser *Parser) readTrailingComment() *liberrors.DetailedError {
+	start_offset := parser.reader.Offset - 1
+
+	contents, err := parser.reader.ReadDelimited(true, '\n')
+	if err != nil && err != io.EOF {
+		return parser.failReading(err)
+	}
+
+	parser.comment = &NodeComment{
+		Contents:    contents,
+		IsInline:    true,
+		NodeContext: parser.makeContext(start_offset),
+	}
+	return nil
+}
+
+// Reads a parenthesised argument list after '(' that can span multiple lines.
+// Line breaks are skipped, while comments are kept as [NodeComment].
+func (parser *Parser) readArgList() (NodeArgs, *liberrors.DetailedError) {
+	var out = NodeArgs{}
+	for {
+		start_offset := parser.reader.Offset
+
+		char, err := parser.reader.Read()
+		if err == io.EOF {
+			return out, parser.failSyntaxHere("missing ')' at the end of an argument list")
+		}
+		if err != nil {
+			return out, parser.failReading(err)
+		}
+
+		switch char {
+
+		case ')':
+			return out, nil
+
+		case '#':
+			comment, err := parser.reader.ReadDelimited(true, '\n')
+			if err != nil && err != io.EOF {
+				return out, parser.failReading(err)
+			}
+			out = append(out, &NodeComment{
+				Contents:    comment,
+				NodeContext: parser.makeContext(start_offset),
+			})
+			continue
+
+		case '\\':
+			// Peeking has to happen before reading, otherwise the character can't be unread
+			parser.reader.Unread()
+			if peek, _ := parser.reader.PeekString(2); peek == "\\\n" {
+				parser.reader.Read()
+				parser.reader.Read()
+				continue
+			}
+			parser.reader.Read()
+		}
+
+		if readers.WhitespaceCharset(char) {
+			continue
+		}
+		parser.reader.Unread()
+		if readers.ArglistTeminatingCharset(char) && !parser.isAtBraceExpansion() && !parser.isAtProcessSubstitution() {
+			parser.reader.Read()
+			return out, parser.failSyntaxHere("unexpected %q inside of an argument list", char)
+		}
+
+		arg, derr := parser.readArg()
+		if arg != nil {
+			out = append(out, arg)
+		}
+		switch derr {
+		case nil, EOA:
+		case EOF:
+			return out, parser.failSyntaxHere("missing ')' at the end of an argument list")
+		default:
+			return out, derr
+		}
+	}
+}
+
+func (parser *Parser) readArg() (Node, *liberrors.DetailedError) {
+	var start_offset = parser.reader.Offset
+	var builder = &segmentBuilder{}
+	// Set when the argument consists of a single '...' string and nothing else
+	var literal *NodeLiteral
+	// Quotes make an argument out of an otherwise empty word, e.g. ""
+	var is_quoted bool
+
+	for {
+		if parser.is_in_condition && parser.isAtComparison() {
+			if builder.Len() == 0 && !is_quoted {
+				return nil, EOA
+			}
+			return parser.makeArg(builder, literal, start_offset, parser.reader.Offset), nil
+		}
+
+		char, err := parser.reader.Read()
+		if err == io.EOF && (builder.Len() != 0 || is_quoted) {
+			return parser.makeArg(builder, literal, start_offset, parser.reader.Offset), EOF
+		}
+		if err != nil {
+			return nil, parser.failReading(err)
+		}
+
+		if char == '{' && !parser.escaped(0, 0) {
+			parser.reader.Unread()
+			is_brace := parser.isAtBraceExpansion()
+			parser.reader.Read()
+			if is_brace {
+				literal = nil
+				if derr := parser.readBraceExpansion(builder); derr != nil {
+					return nil, derr
+				}
+				continue
+			}
+		}
+
+		if (char == '<' || char == '>') && builder.Len() == 0 && !is_quoted {
+			parser.reader.Unread()
+			is_process := parser.isAtProcessSubstitution()
+			parser.reader.Read()
+			if is_process {
+				node, derr := parser.readProcessSubstitution(char)
+				if derr != nil {
+					return nil, derr
+				}
+				return node, nil
+			}
+		}
+
+		if readers.ArglistTeminatingCharset(char) {
+			if builder.Len() == 0 && !is_quoted {
+				if parser.escaped(char, '\n') {
+					return &NodeWhitespace{IsLineBreak: true}, nil
+				}
+				if char != '\n' && readers.WhitespaceCharset(char) {
+					return nil, nil
+				}
+				if char != '\n' {
+					parser.reader.Unread()
+				}
+				return nil, EOA
+			}
+
+			node := parser.makeArg(builder, literal, start_offset, parser.reader.Offset-1)
+
+			switch {
+			case parser.escaped(char, '\n'):
+				parser.reader.Unread()
+				return node, nil
+			case char == '\n' || char == ';':
+				return node, EOA
+			case readers.WhitespaceCharset(char):
+				return node, nil
+			default:
+				parser.reader.Unread()
+				return node, nil
+			}
+		}
+
+		if char == '#' && builder.Len() == 0 && !is_quoted && readers.WhitespaceCharset(parser.reader.Previous()) {
+			if derr := parser.readTrailingComment(); derr != nil {
+				return nil, derr
+			}
+			return nil, EOA
+		}
+
+		literal = nil
+
+		// A condition has no redirections, so '5>3' is a comparison
+		if builder.Len() == 0 && !is_quoted && isDigit(char) && !parser.is_in_condition {
+			parser.reader.Unread()
+			if parser.isAtRedirection() {
+				return nil, EOA
+			}
+			parser.reader.Read()
+		}
+
+		switch char {
+
+		case '\'':
+			contents, err := parser.reader.ReadInsideQuotes(char)
+			if err != nil {
+				return nil, parser.failReading(err)
+			}
+			if builder.Len() == 0 && !is_quoted {
+				literal = &NodeLiteral{Contents: contents}
+			}
+			builder.WriteString(contents)
+			is_quoted = true
+
+		case '"', '`':
+			if derr := parser.readInsideQuotes(char, builder); derr != nil {
+				return nil, derr
+			}
+			is_quoted = true
+
+		case '$':
+			if parser.escaped(0, 0) {
+				builder.WriteRune(char)
+				continue
+			}
+			if derr := parser.readExpansion(builder); derr != nil {
+				return nil, derr
+			}
+
+		case '*', '?', '[':
+			if parser.escaped(0, 0) {
+				builder.WriteRune(char)
+				continue
+			}
+			if derr := parser.readGlob(char, builder); derr != nil {
+				return nil, derr
+			}
+
+		case '~':
+			if parser.escaped(0, 0) || builder.Len() != 0 || is_quoted {
+				builder.WriteRune(char)
+				continue
+			}
+			if derr := parser.readTilde(builder); derr != nil {
+				return nil, derr
+			}
+
+		default:
+			// was the previous character '\\'
+			if parser.escaped(0, 0) {
+				builder.WriteRune('\\')
+			}
+			if char != '\\' {
+				builder.WriteRune(char)
+			}
+		}
+	}
+}
+
+// Reads an unquoted glob pattern, i.e. '*', '**', '?' or '[...]'.
+// A '[' without a matching ']' is kept as-is.
+func (parser *Parser) readGlob(char rune, builder *segmentBuilder) *liberrors.DetailedError {
+	switch char {
+
+	case '*':
+		stars, err := parser.reader.ReadSequence(func(char rune) bool { return char == '*' })
+		if err != nil && err != io.EOF {
+			return parser.failReading(err)
+		}
+		builder.Append(&GlobStringSegment{Pattern: "*" + stars})
+
+	case '?':
+		builder.Append(&GlobStringSegment{Pattern: "?"})
+
+	case '[':
+		contents, err := parser.reader.ReadSequence(func(char rune) bool {
+			return char != ']' && !readers.ArglistTeminatingCharset(char)
+		})
+		if err != nil && err != io.EOF {
+			return parser.failReading(err)
+		}
+		if peek, _ := parser.reader.Peek(); peek != ']' || len(contents) == 0 {
+			builder.WriteString("[" + contents)
+			return nil
+		}
+		parser.reader.Read()
+		builder.Append(&GlobStringSegment{Pattern: "[" + contents + "]"})
+	}
+
+	return nil
+}
+
+// Reads '~' or '~user' at the start of a word after '~'.
+// It's kept as-is unless followed by '/' or the end of the word, e.g. '~user:x'.
+func (parser *Parser) readTilde(builder *segmentBuilder) *liberrors.DetailedError {
+	name, err := parser.reader.ReadSequence(parser.charset(readers.UsernameCharset))
+	if err != nil && err != io.EOF {
+		return parser.failReading(err)
+	}
+
+	peek, err := parser.reader.Peek()
+	if err == nil && peek != '/' && !readers.ArglistTeminatingCharset(rune(peek)) {
+		builder.WriteString("~" + name)
+		return nil
+	}
+
+	builder.Append(&TildeStringSegment{User: name})
+	return nil
+}
+
+// Reads the comma-separated alternatives of a brace expansion after '{', e.g. '{yaml,yaml.bak}'.
+// Each alternative can contain variables, quotes, globs and other brace expansions.
+func (parser *Parser) readBraceExpansion(builder *segmentBuilder) *liberrors.DetailedError {
+	segment := &BraceStringSegment{}
+	alternative := &segmentBuilder{}
+	// Braces that aren't an expansion themselves, e.g. '{a,{b}}'
+	depth := 0
+
+	for {
+		char, err := parser.reader.Read()
+		if err != nil {
+			return parser.failReading(err)
+		}
+
+		switch char {
+
+		case ',':
+			if depth != 0 {
+				alternative.WriteRune(char)
+				continue
+			}
+			segment.Alternatives = append(segment.Alternatives, alternative.Segments())
+			alternative = &segmentBuilder{}
+
+		case '}':
+			if depth != 0 {
+				alternative.WriteRune(char)
+				depth--
+				continue
+			}
+			segment.Alternatives = append(segment.Alternatives, alternative.Segments())
+			builder.Append(segment)
+			return nil
+
+		case '{':
+			parser.reader.Unread()
+			is_brace := parser.isAtBraceExpansion()
+			parser.reader.Read()
+			if !is_brace {
+				alternative.WriteRune(char)
+				depth++
+				continue
+			}
+			if derr := parser.readBraceExpansion(alternative); derr != nil {
+				return derr
+			}
+
+		case '\'':
+			contents, err := parser.reader.ReadInsideQuotes(char)
+			if err != nil {
+				return parser.failReading(err)
+			}
+			alternative.WriteString(contents)
+
+		case '"', '`':
+			if derr := parser.readInsideQuotes(char, alternative); derr != nil {
+				return derr
+			}
+
+		case '$':
+			if derr := parser.readExpansion(alternative); derr != nil {
+				return derr
+			}
+
+		case '*', '?', '[':
+			if derr := parser.readGlob(char, alternative); derr != nil {
+				return derr
+			}
+
+		case '\\':
+			char, err := parser.reader.Read()
+			if err != nil {
+				return parser.failReading(err)
+			}
+			alternative.WriteRune(char)
+
+		default:
+			alternative.WriteRune(char)
+		}
+	}
+}
+
+func (parser *Parser) makeArg(builder *segmentBuilder, literal *NodeLiteral, start_offset, end_offset uint) Node {
+	if literal != nil {
+		literal.NodeContext = parser.makeContext(start_offset)
+		return literal
+	}
+	return &NodeString{
+		Segments:    builder.Segments(),
+		Raw:         parser.reader.Slice(start_offset, end_offset),
+		NodeContext: parser.makeContext(start_offset),
+	}
+}
+
+// Reads a single argument as a [*NodeString], '...' included
+func (parser *Parser) readWord() (*NodeString, *liberrors.DetailedError) {
+	arg, derr := parser.readArg()
+	switch arg := arg.(type) {
+	case *NodeString:
+		return arg, derr
+	case *NodeLiteral:
+		return arg.ToStringNode(), derr
+	}
+	return nil, derr
+}
+
+// Reads the contents of "..." or `...` after the opening quote, expanding variables.
+// Escape sequences are only decoded inside of "...".
+func (parser *Parser) readInsideQuotes(quote rune, builder *segmentBuilder) *liberrors.DetailedError {
+	for {
+		char, err := parser.reader.Read()
+		if err != nil {
+			return parser.failReading(err)
+		}
+
+		switch char {
+
+		case quote:
+			return nil
+
+		case '$':
+			if derr := parser.readExpansion(builder); derr != nil {
+				return derr
+			}
+
+		default:
+			// `...` is kept raw and can span multiple lines
+			if quote == '`' {
+				builder.WriteRune(char)
+				continue
+			}
+
+			switch char {
+			case '\n':
+				return parser.failSyntaxHere("unexpected new line inside of quotes")
+			case '\\':
+				if derr := parser.readEscape(builder); derr != nil {
+					return derr
+				}
+			default:
+				builder.WriteRune(char)
+			}
+		}
+	}
+}
+
+// Decodes an escape sequence inside of "..." after '\\'.
+// Unknown sequences are kept as-is, e.g. "\\d" stays "\\d".
+func (parser *Parser) readEscape(builder *segmentBuilder) *liberrors.DetailedError {
+	char, err := parser.reader.Read()
+	if err != nil {
+		return parser.failReading(err)
+	}
+
+	switch char {
+
+	case 'n':
+		builder.WriteRune('\n')
+
+	case 't':
+		builder.WriteRune('\t')
+
+	case '\\', '"', '$':
+		builder.WriteRune(char)
+
+	case '\n':
+		// line continuation
+
+	case 'u':
+		if peek, _ := parser.reader.Peek(); peek != '{' {
+			builder.WriteString("\\u")
+			return nil
+		}
+		parser.reader.Read()
+
+		hex, err := parser.reader.ReadSequence(isHexDigit)
+		if err != nil {
+			return parser.failReading(err)
+		}
+		if char, _ := parser.reader.Read(); char != '}' {
+			return parser.failSyntaxHere("missing '}' at the end of a unicode escape sequence")
+		}
+
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return parser.failSyntaxHere("invalid unicode escape sequence \\u{%s}", hex)
+		}
+		builder.WriteRune(rune(code))
+
+	default:
+		builder.WriteRune('\\')
+		builder.WriteRune(char)
+	}
+
+	return nil
+}
+
+// Reads a variable or a command substitution after '$'.
+// A '$' that isn't followed by either is kept as-is.
+func (parser *Parser) readExpansion(builder *segmentBuilder) *liberrors.DetailedError {
+	char, err := parser.reader.Read()
+	if err != nil {
+		return parser.failReading(err)
+	}
+
+	switch {
+
+	case char == '(':
+		if peek, _ := parser.reader.Peek(); peek == '(' {
+			parser.reader.Read()
+			segment, derr := parser.readArithmetic()
+			if derr != nil {
+				return derr
+			}
+			builder.Append(segment)
+			return nil
+		}
+
+		segment, derr := parser.readSubstitution()
+		if derr != nil {
+			return derr
+		}
+		builder.Append(segment)
+
+	case char == '{':
+		segment, derr := parser.readVariableExpansion()
+		if derr != nil {
+			return derr
+		}
+		builder.Append(segment)
+
+	case parser.charset(readers.NameCharset)(char):
+		word, err := parser.readVariableName()
+		if err != nil {
+			return parser.failReading(err)
+		}
+		builder.Append(&VariableStringSegment{
+			Name:       string(char) + word,
+			Modifiers:  []StringModifier{},
+			IsOptional: false,
+		})
+
+	default:
+		parser.reader.Unread()
+		builder.WriteRune('$')
+	}
+
+	return nil
+}
+
+// Reads the statements of a command substitution after '$(' up to the closing ')'.
+// Comments are dropped and multiple statements are kept in a subshell [NodeGroup].
+func (parser *Parser) readSubstitution() (*CommandStringSegment, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset - 2
+	out := NodeChildren{}
+
+	// The statements are a part of a word, so the hooks and heredocs of the file don't apply to them
+	hooks, heredocs, awaiting := parser.Hooks, parser.heredocs, parser.awaiting
+	is_in_condition := parser.is_in_condition
+	backup, conditional := parser.container, parser.conditional
+	parser.Hooks, parser.heredocs, parser.awaiting = nil, nil, nil
+	parser.is_in_condition = false
+	parser.container, parser.conditional = &out, nil
+	defer func() {
+		parser.Hooks, parser.heredocs, parser.awaiting = hooks, heredocs, awaiting
+		parser.is_in_condition = is_in_condition
+		parser.container, parser.conditional = backup, conditional
+	}()
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	for {
+		derr := parser.next()
+		if derr == nil {
+			continue
+		}
+		if derr == EOS {
+			break
+		}
+
+		switch derr {
+		case EOF:
+			return nil, parser.failSyntaxHere("missing ')' at the end of a command substitution")
+		case EOB:
+			return nil, parser.failSyntaxHere("expected ')' at the end of a command substitution")
+		}
+		return nil, derr
+	}
+	// The line break after ')' ends the statement that the substitution is a part of
+	if parser.reader.Last() == '\n' {
+		parser.reader.Unread()
+	}
+	if derr := parser.failPendingHeredocs(); derr != nil {
+		return nil, derr
+	}
+	if derr := parser.flushConditional(); derr != nil {
+		return nil, derr
+	}
+
+	statements := slices.DeleteFunc(out, func(node Node) bool {
+		switch node.(type) {
+		case *NodeWhitespace, *NodeComment:
+			return true
+		}
+		return false
+	})
+	switch len(statements) {
+	case 0:
+		return nil, parser.failSyntaxHere("missing a command inside of a command substitution")
+	case 1:
+		return &CommandStringSegment{Node: statements[0]}, nil
+	}
+
+	return &CommandStringSegment{
+		Node: &NodeGroup{
+			IsSubshell:   true,
+			NodeChildren: statements,
+			NodeContext:  parser.makeContext(start_offset),
+		},
+	}, nil
+}
+
+// Reads the expression of an arithmetic expansion after '$(('
+func (parser *Parser) readArithmetic() (*ArithmeticStringSegment, *liberrors.DetailedError) {
+	expression, derr := parser.readArithmeticExpression(0)
+	if derr == EOF {
+		return nil, parser.failSyntaxHere("missing '))' at the end of an arithmetic expansion")
+	}
+	if derr != nil {
+		return nil, derr
+	}
+
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+	if peek, _ := parser.reader.PeekString(2); peek != "))" {
+		return nil, parser.failSyntaxHere("missing '))' at the end of an arithmetic expansion")
+	}
+	parser.reader.Read()
+	parser.reader.Read()
+
+	return &ArithmeticStringSegment{Expression: expression}, nil
+}
+
+// Reads the operators of an arithmetic expansion, see [readBinary]
+func (parser *Parser) readArithmeticExpression(min_precedence int) (ArithmeticNode, *liberrors.DetailedError) {
+	return readBinary(parser, min_precedence, binaryGrammar[ArithmeticOperator, ArithmeticNode]{
+		precedence: ARITHMETIC_PRECEDENCE,
+		blanks:     readers.WhitespaceCharset,
+		operand:    parser.readArithmeticOperand,
+		join: func(left ArithmeticNode, operator ArithmeticOperator, right ArithmeticNode, _ uint) ArithmeticNode {
+			return &ArithmeticBinary{Left: left, Operator: operator, Right: right}
+		},
+	})
+}
+
+func (parser *Parser) readArithmeticOperand() (ArithmeticNode, *liberrors.DetailedError) {
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	char, err := parser.reader.Read()
+	if err != nil {
+		return nil, parser.failReading(err)
+	}
+
+	switch {
+
+	case isDigit(char):
+		digits, err := parser.reader.ReadSequence(isDigit)
+		if err != nil && err != io.EOF {
+			return nil, parser.failReading(err)
+		}
+		value, err := strconv.ParseInt(string(char)+digits, 10, 64)
+		if err != nil {
+			return nil, parser.failSyntaxHere("integer %s is out of range", string(char)+digits)
+		}
+		return &ArithmeticNumber{Value: value}, nil
+
+	case char == '(':
+		inner, derr := parser.readArithmeticExpression(0)
+		if derr != nil {
+			return nil, derr
+		}
+		parser.reader.ReadSequence(readers.WhitespaceCharset)
+		if char, _ := parser.reader.Read(); char != ')' {
+			return nil, parser.failSyntaxHere("missing ')' inside of an arithmetic expansion")
+		}
+		return &ArithmeticGroup{Inner: inner}, nil
+
+	case char == '-' || char == '+':
+		operand, derr := parser.readArithmeticOperand()
+		if derr != nil {
+			return nil, derr
+		}
+		return &ArithmeticUnary{Operator: ArithmeticOperator(char), Operand: operand}, nil
+
+	case char == '$' || (parser.charset(readers.AssignmentCharset)(char) && !isDigit(char)):
+		name, err := parser.reader.ReadSequence(parser.charset(readers.AssignmentCharset))
+		if err != nil && err != io.EOF {
+			return nil, parser.failReading(err)
+		}
+		if char != '$' {
+			name = string(char) + name
+		}
+		if name == "" {
+			return nil, parser.failSyntaxHere("missing variable name after '$' inside of an arithmetic expansion")
+		}
+		return &ArithmeticVariable{Name: name}, nil
+	}
+
+	return nil, parser.failSyntaxHere("unexpected %q inside of an arithmetic expansion", char)
+}
+
+// Reads the statement of '<(...)' or '>(...)' after the direction
+func (parser *Parser) readProcessSubstitution(direction rune) (*NodeProcessSubst, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset - 1
+
+	parser.reader.Read() // '('
+	parser.reader.ReadSequence(readers.WhitespaceCharset)
+
+	node, derr := parser.readChain()
+	if derr == EOF {
+		return nil, parser.failSyntaxHere("missing ')' at the end of a process substitution")
+	}
+	if derr != nil {
+		return nil, derr
+	}
+
+	char, err := parser.reader.Read()
+	if err != nil && err != io.EOF {
+		return nil, parser.failReading(err)
+	}
+	if char != ')' {
+		return nil, parser.failSyntaxHere("missing ')' at the end of a process substitution")
+	}
+
+	return &NodeProcessSubst{
+		Direction:   ProcessDirection(direction),
+		Node:        node,
+		NodeContext: parser.makeContext(start_offset),
+	}, nil
+}
+
+func (parser *Parser) readChildren() (NodeChildren, error) {
+	out := NodeChildren{}
+	backup, conditional := parser.container, parser.conditional
+	parser.container, parser.conditional = &out, nil
+	defer func() {
+		parser.container, parser.conditional = backup, conditional
+	}()
 
 	char, err := parser.reader.Read()
 	if err != nil {
@@ -453,6 +1793,9 @@ func (parser *Parser) readChildren() (NodeChildren, error) {
 			continue
 
 		case EOB:
+			if derr := parser.flushConditional(); derr != nil {
+				return out, derr
+			}
 			return out, nil
 
 		case EOF:
@@ -465,7 +1808,7 @@ func (parser *Parser) readChildren() (NodeChildren, error) {
 }
 
 func (parser *Parser) readVariableExpansion() (*VariableStringSegment, *liberrors.DetailedError) {
-	name, err := parser.reader.ReadSequence(readers.NameCharset)
+	name, err := parser.reader.ReadSequence(parser.charset(readers.NameCharset))
 	if err != nil {
 		return nil, parser.failReading(err)
 	}
@@ -475,9 +1818,24 @@ func (parser *Parser) readVariableExpansion() (*VariableStringSegment, *liberror
 		return nil, parser.failReading(err)
 	}
 
-	var optional bool
+	segment := &VariableStringSegment{
+		Name:      name,
+		Modifiers: []StringModifier{},
+	}
+
+	if char == '[' {
+		if derr := parser.readVariableIndex(segment); derr != nil {
+			return nil, derr
+		}
+
+		char, err = parser.reader.Read()
+		if err != nil {
+			return nil, parser.failReading(err)
+		}
+	}
+
 	if char == '?' {
-		optional = true
+		segment.IsOptional = true
 
 		char, err = parser.reader.Read()
 		if err != nil {
@@ -485,12 +1843,12 @@ func (parser *Parser) readVariableExpansion() (*VariableStringSegment, *liberror
 		}
 	}
 
+	if segment.IsOptional && (char == '=' || char == '+' || char == '!') {
+		return segment, parser.readVariableFallback(segment, char)
+	}
+
 	if char == '}' {
-		return &VariableStringSegment{
-			Name:       name,
-			Modifiers:  []StringModifier{},
-			IsOptional: optional,
-		}, nil
+		return segment, nil
 	}
 
 	if char != ':' {
@@ -507,21 +1865,114 @@ func (parser *Parser) readVariableExpansion() (*VariableStringSegment, *liberror
 		}
 		if derr != nil {
 			if derr == EOA {
-				return &VariableStringSegment{
-					Name:       name,
-					Modifiers:  SortNotModifierToEnd(modifiers),
-					IsOptional: optional,
-				}, nil
+				segment.Modifiers = SortNotModifierToEnd(modifiers)
+				return segment, nil
 			}
 			return nil, derr
 		}
 	}
 }
 
+// Reads '[0]', '[-1]' or '[@]' after '['
+func (parser *Parser) readVariableIndex(segment *VariableStringSegment) *liberrors.DetailedError {
+	contents, err := parser.reader.ReadSequence(func(char rune) bool {
+		return char != ']' && char != '}' && char != '\n'
+	})
+	if err != nil {
+		return parser.failReading(err)
+	}
+	if char, _ := parser.reader.Read(); char != ']' {
+		return parser.failSyntaxHere("missing ']' at the end of a variable index")
+	}
+
+	if contents == "@" {
+		segment.IsSplat = true
+		return nil
+	}
+
+	index, err := strconv.Atoi(contents)
+	if err != nil {
+		return parser.failSyntaxHere("invalid index %q in a variable expansion", contents)
+	}
+	segment.Index = &index
+	return nil
+}
+
+// Reads '${name?=default}', '${name?+alternate}' or '${name?!message}' after the operator
+func (parser *Parser) readVariableFallback(segment *VariableStringSegment, operator rune) *liberrors.DetailedError {
+	value, derr := parser.readExpansionValue()
+	if derr != nil {
+		return derr
+	}
+
+	switch operator {
+	case '=':
+		segment.Default = value
+	case '+':
+		segment.Alternate = value
+	case '!':
+		segment.ErrorMessage = value
+	}
+	return nil
+}
+
+// Reads the value of '${name?=value}' and similar expansions up to and including the closing '}'
+func (parser *Parser) readExpansionValue() (*NodeString, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+	builder := &segmentBuilder{}
+
+	for {
+		char, err := parser.reader.Read()
+		if err != nil {
+			return nil, parser.failReading(err)
+		}
+
+		switch char {
+
+		case '}':
+			return &NodeString{
+				Segments:    builder.Segments(),
+				Raw:         parser.reader.Slice(start_offset, parser.reader.Offset-1),
+				NodeContext: parser.makeContext(start_offset),
+			}, nil
+
+		case '\n':
+			return nil, parser.failSyntaxHere("unexpected new line inside of a variable expansion")
+
+		case '$':
+			if derr := parser.readExpansion(builder); derr != nil {
+				return nil, derr
+			}
+
+		case '\'':
+			contents, err := parser.reader.ReadInsideQuotes(char)
+			if err != nil {
+				return nil, parser.failReading(err)
+			}
+			builder.WriteString(contents)
+
+		case '"', '`':
+			if derr := parser.readInsideQuotes(char, builder); derr != nil {
+				return nil, derr
+			}
+
+		case '\\':
+			char, err := parser.reader.Read()
+			if err != nil {
+				return nil, parser.failReading(err)
+			}
+			builder.WriteRune(char)
+
+		default:
+			builder.WriteRune(char)
+		}
+	}
+}
+
 func (parser *Parser) readVariableModifier() (StringModifier, *liberrors.DetailedError) {
 	offset_start := parser.reader.Offset
 
-	modifier_name, err := parser.reader.ReadSequence(readers.NameCharset)
+	modifier_name, err := parser.reader.ReadSequence(parser.charset(readers.NameCharset))
 	if err != nil {
 		return StringModifier{}, parser.failReading(err)
 	}
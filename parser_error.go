@@ -13,6 +13,8 @@ var UNEXPECTED_EOF = &liberrors.DetailedError{Name: "EOF", Details: "Unexpected
 
 var EOB = &liberrors.DetailedError{Name: "EOB", Details: "End of Block"}
 
+var EOS = &liberrors.DetailedError{Name: "EOS", Details: "End of Subshell"}
+
 var EOA = &liberrors.DetailedError{Name: "EOA", Details: "End of Arguments"}
 
 func (parser *Parser) fillErrorTrace(derr *liberrors.DetailedError) {
@@ -24,7 +24,7 @@ func ExcludeHook[T Node](node Node) (Node, *liberrors.DetailedError) {
 func NoShebangHook(node Node) (Node, *liberrors.DetailedError) {
 	switch node := node.(type) {
 	case *NodeComment:
-		if strings.HasPrefix(node.Contents, "!") {
+		if !node.IsInline && strings.HasPrefix(node.Contents, "!") {
 			return nil, nil
 		}
 	}
@@ -1,13 +1,32 @@
 package libparser
 
 import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
 	liberrors "github.com/tomefile/lib-errors"
+	"github.com/tomefile/lib-parser/readers"
 )
 
+// How many digits are looked ahead for a file descriptor of a redirection
+const MAX_FD_DIGITS = 4
+
+// How many characters are looked ahead for the name of an environment variable assignment
+const MAX_ASSIGNMENT_NAME = 256
+
+// How many characters are looked ahead for the closing '}' of a brace expansion
+const MAX_BRACE_LENGTH = 1024
+
 func (parser *Parser) makeContext(offset uint) NodeContext {
+	end := parser.reader.Offset
+	// The trailing comment is a sibling of the statement, so the statement ends before it
+	if parser.comment != nil && parser.comment.OffsetStart >= offset {
+		end = min(end, parser.comment.OffsetStart)
+	}
 	return NodeContext{
 		OffsetStart: offset,
-		OffsetEnd:   parser.reader.Offset,
+		OffsetEnd:   end,
 	}
 }
 
@@ -55,6 +74,24 @@ func (parser *Parser) process(node Node) (Node, *liberrors.DetailedError) {
 }
 
 func (parser *Parser) write(node Node) (derr *liberrors.DetailedError) {
+	// Hooks must not see a heredoc without its body, e.g. in 'cat <<EOF; echo ok'
+	if len(parser.heredocs) != 0 {
+		parser.awaiting = append(parser.awaiting, pendingWrite{node: node, container: parser.container})
+		return nil
+	}
+
+	switch node.(type) {
+	case *NodeWhitespace, *NodeComment:
+		if parser.conditional != nil {
+			parser.conditional.tail = append(parser.conditional.tail, node)
+			return nil
+		}
+	default:
+		if derr := parser.flushConditional(); derr != nil {
+			return derr
+		}
+	}
+
 	node, derr = parser.process(node)
 	if derr != nil || node == nil {
 		return derr
@@ -64,6 +101,382 @@ func (parser *Parser) write(node Node) (derr *liberrors.DetailedError) {
 	return nil
 }
 
+// Applies [Parser.StrictASCII] to a charset of names
+func (parser *Parser) charset(comparator readers.CharsetComparator) readers.CharsetComparator {
+	if parser.StrictASCII {
+		return readers.ASCIIOnly(comparator)
+	}
+	return comparator
+}
+
+// Whether the last read character has terminated the current statement
+func (parser *Parser) hasStatementEnded() bool {
+	last := parser.reader.Last()
+	return last == '\n' || last == ';'
+}
+
+// Whether the statement is followed by a single '&' that runs it in background
+func (parser *Parser) isAtBackground() bool {
+	if parser.hasStatementEnded() {
+		return false
+	}
+	peek, _ := parser.reader.PeekString(2)
+	return strings.HasPrefix(peek, "&") && peek != string(CHAIN_AND)
+}
+
+// Whether the upcoming characters negate a statement, i.e. '!' followed by a blank.
+// It doesn't clash with macros, which end with '!' instead.
+func (parser *Parser) isAtNegation() bool {
+	peek, _ := parser.reader.PeekString(2)
+	return len(peek) == 2 && peek[0] == '!' && readers.BlankCharset(rune(peek[1]))
+}
+
+// Whether the upcoming characters open a group, i.e. '(' or '{' followed by whitespace
+func (parser *Parser) isAtGroup() bool {
+	peek, _ := parser.reader.PeekString(2)
+	switch {
+	case strings.HasPrefix(peek, "("):
+		return true
+	case strings.HasPrefix(peek, "{") && len(peek) == 2:
+		return readers.WhitespaceCharset(rune(peek[1]))
+	}
+	return false
+}
+
+// Whether the upcoming characters start a redirection, e.g. '>', '2>', '&>' or '<'
+func (parser *Parser) isAtRedirection() bool {
+	peek, _ := parser.reader.PeekString(MAX_FD_DIGITS + 2)
+	if strings.HasPrefix(peek, "&>") {
+		return true
+	}
+
+	peek = strings.TrimLeftFunc(peek, isDigit)
+	if strings.HasPrefix(peek, "<(") || strings.HasPrefix(peek, ">(") {
+		return false
+	}
+	return strings.HasPrefix(peek, "<") || strings.HasPrefix(peek, ">")
+}
+
+// Whether the upcoming characters start a process substitution, i.e. '<(' or '>('
+func (parser *Parser) isAtProcessSubstitution() bool {
+	peek, _ := parser.reader.PeekString(2)
+	return peek == "<(" || peek == ">("
+}
+
+// Writes a statement followed by its trailing comment
+func (parser *Parser) writeStatement(node Node, comment *NodeComment) *liberrors.DetailedError {
+	if derr := parser.write(node); derr != nil {
+		return derr
+	}
+	if comment != nil {
+		return parser.write(comment)
+	}
+	return nil
+}
+
+// Whether the upcoming characters are an unescaped '==' or '!=' of a condition
+func (parser *Parser) isAtComparison() bool {
+	if parser.reader.Last() == '\\' {
+		return false
+	}
+	peek, _ := parser.reader.PeekString(2)
+	return peek == string(EXPR_EQUAL) || peek == string(EXPR_NOT_EQUAL)
+}
+
+// Reads the rest of a variable name after its first character.
+// Inside of a condition the name stops before '!=', e.g. '$a!=b'.
+func (parser *Parser) readVariableName() (string, error) {
+	if !parser.is_in_condition {
+		return parser.reader.ReadSequence(parser.charset(readers.NameCharset))
+	}
+
+	var builder strings.Builder
+	for !parser.isAtComparison() {
+		peek, _ := parser.reader.PeekString(utf8.UTFMax)
+		if char, _ := utf8.DecodeRuneInString(peek); !parser.charset(readers.NameCharset)(char) {
+			break
+		}
+		char, err := parser.reader.Read()
+		if err != nil {
+			return builder.String(), err
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String(), nil
+}
+
+// An ':if' chain that is held back until it can't be followed by another branch
+type pendingConditional struct {
+	node *NodeConditional
+	// Whitespace and comments that were read after the last branch
+	tail []Node
+}
+
+// Attaches an ':elif' or ':else' branch to the pending [NodeConditional].
+// Whitespace in between is dropped, while comments are written after the conditional.
+func (parser *Parser) linkBranch(branch *NodeDirective) *liberrors.DetailedError {
+	if parser.conditional == nil {
+		return parser.failSyntax(
+			branch.OffsetStart,
+			"':%s' must follow the body of ':if' or ':elif'",
+			branch.Name,
+		)
+	}
+	conditional := parser.conditional.node
+
+	switch {
+	case conditional.Branches[len(conditional.Branches)-1].Name == "else":
+		return parser.failSyntax(
+			branch.OffsetStart,
+			"':%s' can't follow ':else', which must be the last branch",
+			branch.Name,
+		)
+	case branch.Name == "else" && len(branch.NodeArgs) != 0:
+		return parser.failSyntax(
+			branch.OffsetStart,
+			"':else' doesn't take a condition, use ':elif' instead",
+		)
+	}
+
+	parser.conditional.tail = slices.DeleteFunc(parser.conditional.tail, func(node Node) bool {
+		_, ok := node.(*NodeWhitespace)
+		return ok
+	})
+	conditional.Branches = append(conditional.Branches, branch)
+	conditional.OffsetEnd = branch.OffsetEnd
+	return nil
+}
+
+// Writes the pending [NodeConditional] followed by its tail, if there is one
+func (parser *Parser) flushConditional() *liberrors.DetailedError {
+	pending := parser.conditional
+	if pending == nil {
+		return nil
+	}
+	parser.conditional = nil
+
+	if derr := parser.write(pending.node); derr != nil {
+		return derr
+	}
+	for _, node := range pending.tail {
+		if derr := parser.write(node); derr != nil {
+			return derr
+		}
+	}
+	return nil
+}
+
+// A node that was read while a heredoc body was pending
+type pendingWrite struct {
+	node Node
+	// The container that was current when the node was read, e.g. the one of a group
+	container *NodeChildren
+}
+
+// Fails if a heredoc is still waiting for its body when there is nothing left to read
+func (parser *Parser) failPendingHeredocs() *liberrors.DetailedError {
+	if len(parser.heredocs) == 0 {
+		return nil
+	}
+	return parser.failSyntaxHere("missing heredoc delimiter %q", parser.heredocs[0].Delimiter)
+}
+
+// Writes the nodes that waited for heredoc bodies into the containers they were read in
+func (parser *Parser) flushAwaiting() *liberrors.DetailedError {
+	awaiting := parser.awaiting
+	parser.awaiting = nil
+
+	for _, pending := range awaiting {
+		if pending.container == parser.container {
+			if derr := parser.write(pending.node); derr != nil {
+				return derr
+			}
+			continue
+		}
+
+		// The group that the node belongs to has already been closed
+		backup, conditional := parser.container, parser.conditional
+		parser.container, parser.conditional = pending.container, nil
+		derr := parser.write(pending.node)
+		parser.container, parser.conditional = backup, conditional
+		if derr != nil {
+			return derr
+		}
+	}
+	return nil
+}
+
+// Returns the trailing comment of the current statement and resets it
+func (parser *Parser) takeComment() *NodeComment {
+	comment := parser.comment
+	parser.comment = nil
+	return comment
+}
+
+// Whether the upcoming word assigns an environment variable, e.g. 'GOOS=linux'
+func (parser *Parser) isAtAssignment() bool {
+	peek, _ := parser.reader.PeekString(MAX_ASSIGNMENT_NAME + 1)
+	for i, char := range peek {
+		if char == '=' {
+			return i != 0
+		}
+		if !parser.charset(readers.AssignmentCharset)(char) || (i == 0 && isDigit(char)) {
+			return false
+		}
+	}
+	return false
+}
+
+// Whether the upcoming characters form a brace expansion, e.g. '{a,b}'.
+// It has to be closed within the same word and contain at least one ',' of its own.
+func (parser *Parser) isAtBraceExpansion() bool {
+	peek, _ := parser.reader.PeekString(MAX_BRACE_LENGTH)
+	if !strings.HasPrefix(peek, "{") {
+		return false
+	}
+	depth := 1
+	has_comma := false
+	// The opening quote of the string that is being skipped
+	var quote rune
+	is_escaped := false
+
+	// A rune that is cut off at the end of the peek window decodes into
+	// [utf8.RuneError], which stops nothing, so the loop runs out without a match.
+	for _, char := range peek[1:] {
+		switch {
+		case is_escaped:
+			is_escaped = false
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\\':
+			is_escaped = true
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '{':
+			depth++
+		case char == '}':
+			depth--
+			if depth == 0 {
+				return has_comma
+			}
+		case char == ',':
+			if depth == 1 {
+				has_comma = true
+			}
+		case readers.ArglistTeminatingCharset(char):
+			return false
+		}
+	}
+	return false
+}
+
 func (parser *Parser) escaped(char, comp rune) bool {
 	return parser.reader.Previous() == '\\' && char == comp
 }
+
+// ————————————————————————————————
+
+// Accumulates string segments, merging consecutive characters into a single [LiteralStringSegment].
+type segmentBuilder struct {
+	segments SegmentedString
+	literal  strings.Builder
+}
+
+func (builder *segmentBuilder) WriteRune(char rune) {
+	builder.literal.WriteRune(char)
+}
+
+func (builder *segmentBuilder) WriteString(contents string) {
+	builder.literal.WriteString(contents)
+}
+
+func (builder *segmentBuilder) Append(segment StringSegment) {
+	builder.flush()
+	builder.segments = append(builder.segments, segment)
+}
+
+func (builder *segmentBuilder) Len() int {
+	return len(builder.segments) + builder.literal.Len()
+}
+
+// Returns the collected segments. An empty builder results in a single empty literal.
+func (builder *segmentBuilder) Segments() SegmentedString {
+	if len(builder.segments) == 0 && builder.literal.Len() == 0 {
+		return SegmentedString{&LiteralStringSegment{Contents: ""}}
+	}
+	builder.flush()
+	return builder.segments
+}
+
+func (builder *segmentBuilder) flush() {
+	if builder.literal.Len() == 0 {
+		return
+	}
+	builder.segments = append(builder.segments, &LiteralStringSegment{
+		Contents: builder.literal.String(),
+	})
+	builder.literal.Reset()
+}
+
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isHexDigit(char rune) bool {
+	return isDigit(char) ||
+		(char >= 'a' && char <= 'f') ||
+		(char >= 'A' && char <= 'F')
+}
+
+// ————————————————————————————————
+
+// Operators of a binary expression and how to read and join its operands
+type binaryGrammar[O ~string, N any] struct {
+	precedence map[O]int
+	// Characters that are skipped around operators
+	blanks  readers.CharsetComparator
+	operand func() (N, *liberrors.DetailedError)
+	join    func(left N, operator O, right N, start_offset uint) N
+}
+
+// Reads binary operations that bind at least as tightly as min_precedence
+func readBinary[O ~string, N any](
+	parser *Parser,
+	min_precedence int,
+	grammar binaryGrammar[O, N],
+) (N, *liberrors.DetailedError) {
+	start_offset := parser.reader.Offset
+
+	left, derr := grammar.operand()
+	if derr != nil {
+		return left, derr
+	}
+
+	for !parser.hasStatementEnded() {
+		parser.reader.ReadSequence(grammar.blanks)
+		peek, _ := parser.reader.PeekString(2)
+
+		operator := O(peek)
+		if _, exists := grammar.precedence[operator]; !exists && len(peek) != 0 {
+			operator = O(peek[:1])
+		}
+		precedence, exists := grammar.precedence[operator]
+		if !exists || precedence < min_precedence {
+			break
+		}
+		for range len(operator) {
+			parser.reader.Read()
+		}
+		parser.reader.ReadSequence(grammar.blanks)
+
+		right, derr := readBinary(parser, precedence+1, grammar)
+		if derr != nil {
+			return right, derr
+		}
+		left = grammar.join(left, operator, right, start_offset)
+	}
+
+	return left, nil
+}
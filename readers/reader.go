@@ -41,6 +41,14 @@ func (reader *Reader) Previous() rune {
 	return reader.buffer[len(reader.buffer)-2]
 }
 
+// Returns the most recently read character
+func (reader *Reader) Last() rune {
+	if len(reader.buffer) == 0 {
+		return 0
+	}
+	return reader.buffer[len(reader.buffer)-1]
+}
+
 // Returns the next byte without advancing the reader
 func (reader *Reader) Peek() (byte, error) {
 	data, err := reader.Inner.Peek(1)
@@ -50,6 +58,18 @@ func (reader *Reader) Peek() (byte, error) {
 	return data[0], err
 }
 
+// Returns up to n next bytes without advancing the reader
+func (reader *Reader) PeekString(n int) (string, error) {
+	data, err := reader.Inner.Peek(n)
+	return string(data), err
+}
+
+// Returns the characters that were read between two offsets
+func (reader *Reader) Slice(from, to uint) string {
+	to = min(to, uint(len(reader.buffer)))
+	return string(reader.buffer[min(from, to):to])
+}
+
 func (reader *Reader) Unread() {
 	reader.Inner.UnreadRune()
 	reader.Offset--
@@ -5,20 +5,48 @@ import (
 	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 type CharsetComparator func(rune) bool
 
+func isLetterOrDigit(in rune) bool {
+	if in < utf8.RuneSelf {
+		return (in >= 'A' && in <= 'Z') ||
+			(in >= 'a' && in <= 'z') ||
+			(in >= '0' && in <= '9')
+	}
+	return unicode.IsLetter(in) || unicode.IsDigit(in)
+}
+
+// Narrows a charset down to its ASCII characters
+func ASCIIOnly(comparator CharsetComparator) CharsetComparator {
+	return func(in rune) bool {
+		return in < utf8.RuneSelf && comparator(in)
+	}
+}
+
+// Names of directives, tomes and variables, e.g. 'include' or 'größe'
 func NameCharset(in rune) bool {
-	return (in >= 'A' && in <= 'Z') ||
-		(in >= 'a' && in <= 'z') ||
-		(in >= '0' && in <= '9') ||
+	return isLetterOrDigit(in) ||
 		in == '_' ||
 		in == '-' ||
 		in == '!' ||
 		in == '$'
 }
 
+// Names of environment variables, e.g. 'CGO_ENABLED'
+func AssignmentCharset(in rune) bool {
+	return isLetterOrDigit(in) || in == '_'
+}
+
+// Names of user accounts, e.g. 'www-data' in '~www-data'
+func UsernameCharset(in rune) bool {
+	return AssignmentCharset(in) ||
+		in == '-' ||
+		in == '.'
+}
+
 func FilenameCharset(in rune) bool {
 	return NameCharset(in) ||
 		in == '.' ||
@@ -37,10 +65,16 @@ func WhitespaceCharset(in rune) bool {
 	return unicode.IsSpace(in)
 }
 
+// Whitespace that doesn't break the line
+func BlankCharset(in rune) bool {
+	return in == ' ' || in == '\t'
+}
+
 func ArglistTeminatingCharset(in rune) bool {
 	return WhitespaceCharset(in) ||
 		in == ';' ||
 		in == '|' ||
+		in == '&' ||
 		in == '>' ||
 		in == '<' ||
 		in == '(' ||
@@ -87,10 +121,10 @@ func (reader *Reader) ReadDelimited(including bool, delims ...rune) (string, err
 	}
 }
 
+// Reads the raw contents of a quoted string after the opening quote, without decoding escape sequences.
+// Variables are expanded by the parser, which reads "..." and `...` on its own.
 func (reader *Reader) ReadInsideQuotes(quote rune) (string, error) {
-	// TODO: Parse variables
 	var builder strings.Builder
-	is_escaped := false
 
 	for {
 		char, err := reader.Read()
@@ -101,20 +135,12 @@ func (reader *Reader) ReadInsideQuotes(quote rune) (string, error) {
 		switch char {
 
 		case quote:
-			if !is_escaped {
-				return builder.String(), nil
-			}
-
-		case '\\':
-			is_escaped = true
+			return builder.String(), nil
 
 		case '\n':
-			if !is_escaped {
-				return builder.String(), errors.New("unexpected new line inside of quotes")
-			}
+			return builder.String(), errors.New("unexpected new line inside of quotes")
 		}
 
-		is_escaped = false
 		builder.WriteRune(char)
 	}
 }
@@ -25,7 +25,7 @@ var ExpectedData = []DataTestCase{
 				},
 				&libparser.NodeWhitespace{},
 				&libparser.NodeExec{
-					Name: "./local/echo",
+					Name: libparser.NewSimpleNodeString("./local/echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("Hello World!"),
 						&libparser.NodeWhitespace{IsLineBreak: true},
@@ -38,10 +38,16 @@ var ExpectedData = []DataTestCase{
 				&libparser.NodeDirective{
 					Name: "section",
 					NodeArgs: libparser.NodeArgs{
-						&libparser.NodeExec{
-							Name: "echo",
-							NodeArgs: libparser.NodeArgs{
-								libparser.NewSimpleNodeString("/tmp/filename.png"),
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeExec{
+										Name: libparser.NewSimpleNodeString("echo"),
+										NodeArgs: libparser.NodeArgs{
+											libparser.NewSimpleNodeString("/tmp/filename.png"),
+										},
+									},
+								},
 							},
 						},
 						&libparser.NodeLiteral{
@@ -52,15 +58,17 @@ var ExpectedData = []DataTestCase{
 						&libparser.NodeDirective{
 							Name: "assert",
 							NodeArgs: libparser.NodeArgs{
-								&libparser.NodeString{
-									Segments: libparser.SegmentedString{
-										&libparser.VariableStringSegment{
-											Name: "build_dir",
-											Modifiers: []libparser.StringModifier{
-												getModifierSafe(libparser.MOD_IS_DIR),
-												getModifierSafe(libparser.MOD_NOT),
+								&libparser.NodeExpr{
+									Operand: &libparser.NodeString{
+										Segments: libparser.SegmentedString{
+											&libparser.VariableStringSegment{
+												Name: "build_dir",
+												Modifiers: []libparser.StringModifier{
+													getModifierSafe(libparser.MOD_IS_DIR),
+													getModifierSafe(libparser.MOD_NOT),
+												},
+												IsOptional: true,
 											},
-											IsOptional: true,
 										},
 									},
 								},
@@ -100,25 +108,31 @@ var ExpectedData = []DataTestCase{
 							NodeChildren: libparser.NodeChildren{
 								&libparser.NodeRedirect{
 									Source: &libparser.NodeExec{
-										Name: "patch",
+										Name: libparser.NewSimpleNodeString("patch"),
 										NodeArgs: libparser.NodeArgs{
 											libparser.NewSimpleNodeString("-s"),
 											&libparser.NodeWhitespace{IsLineBreak: true},
 											libparser.NewSimpleNodeString("-o"),
 											libparser.NewSimpleNodeString("/tmp/patched-file.json"),
 											&libparser.NodeWhitespace{IsLineBreak: true},
-											&libparser.NodeExec{
-												Name: "realpath",
-												NodeArgs: libparser.NodeArgs{
-													&libparser.NodeString{
-														Segments: libparser.SegmentedString{
-															&libparser.LiteralStringSegment{
-																Contents: "../something/something/",
-															},
-															&libparser.VariableStringSegment{
-																Name:       "basename",
-																Modifiers:  []libparser.StringModifier{},
-																IsOptional: false,
+											&libparser.NodeString{
+												Segments: libparser.SegmentedString{
+													&libparser.CommandStringSegment{
+														Node: &libparser.NodeExec{
+															Name: libparser.NewSimpleNodeString("realpath"),
+															NodeArgs: libparser.NodeArgs{
+																&libparser.NodeString{
+																	Segments: libparser.SegmentedString{
+																		&libparser.LiteralStringSegment{
+																			Contents: "../something/something/",
+																		},
+																		&libparser.VariableStringSegment{
+																			Name:       "basename",
+																			Modifiers:  []libparser.StringModifier{},
+																			IsOptional: false,
+																		},
+																	},
+																},
 															},
 														},
 													},
@@ -136,7 +150,13 @@ var ExpectedData = []DataTestCase{
 											},
 										},
 									},
-									Stdout: libparser.NewSimpleNodeString("/some/output"),
+									Operations: []libparser.RedirectOperation{
+										{
+											Fd:     1,
+											Mode:   libparser.REDIRECT_WRITE,
+											Target: libparser.NewSimpleNodeString("/some/output"),
+										},
+									},
 								},
 							},
 						},
@@ -153,13 +173,73 @@ var ExpectedData = []DataTestCase{
 			},
 		},
 	},
+	{
+		Filename: "01_syntax_unicode.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{
+				"構築": nil,
+			},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeComment{Contents: " Names in other languages"},
+				&libparser.NodeDirective{
+					Name: "tâche",
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:      "größe",
+									Modifiers: []libparser.StringModifier{},
+								},
+							},
+						},
+					},
+					NodeChildren: libparser.NodeChildren{
+						&libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("echo"),
+							NodeArgs: libparser.NodeArgs{
+								&libparser.NodeString{
+									Segments: libparser.SegmentedString{
+										&libparser.VariableStringSegment{
+											Name: "größe",
+											Modifiers: []libparser.StringModifier{
+												getModifierSafe(libparser.MOD_LENGTH),
+											},
+										},
+									},
+								},
+								&libparser.NodeString{
+									Segments: libparser.SegmentedString{
+										&libparser.ArithmeticStringSegment{
+											Expression: &libparser.ArithmeticBinary{
+												Left:     &libparser.ArithmeticVariable{Name: "größe"},
+												Operator: libparser.ARITHMETIC_ADD,
+												Right:    &libparser.ArithmeticNumber{Value: 1},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeWhitespace{},
+				&libparser.NodeDirective{
+					Name: "tome",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("構築"),
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+			},
+		},
+	},
 	{
 		Filename: "02_directive_body.tome",
 		Expect: &libparser.NodeRoot{
 			Tomes: map[string]*libparser.NodeDirective{},
 			NodeChildren: libparser.NodeChildren{
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("1"),
 					},
@@ -172,13 +252,13 @@ var ExpectedData = []DataTestCase{
 					},
 					NodeChildren: libparser.NodeChildren{
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								&libparser.NodeLiteral{Contents: "1.1"},
 							},
 						},
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("1.2"),
 							},
@@ -194,7 +274,7 @@ var ExpectedData = []DataTestCase{
 			Tomes: map[string]*libparser.NodeDirective{},
 			NodeChildren: libparser.NodeChildren{
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("1"),
 					},
@@ -207,13 +287,13 @@ var ExpectedData = []DataTestCase{
 					},
 					NodeChildren: libparser.NodeChildren{
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								&libparser.NodeLiteral{Contents: "1.1"},
 							},
 						},
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("1.2"),
 							},
@@ -227,13 +307,13 @@ var ExpectedData = []DataTestCase{
 							NodeChildren: libparser.NodeChildren{
 								&libparser.NodeComment{Contents: " This is nested inside"},
 								&libparser.NodeExec{
-									Name: "echo",
+									Name: libparser.NewSimpleNodeString("echo"),
 									NodeArgs: libparser.NodeArgs{
 										libparser.NewSimpleNodeString("2.1"),
 									},
 								},
 								&libparser.NodeExec{
-									Name: "echo",
+									Name: libparser.NewSimpleNodeString("echo"),
 									NodeArgs: libparser.NodeArgs{
 										libparser.NewSimpleNodeString("2.2"),
 									},
@@ -242,7 +322,7 @@ var ExpectedData = []DataTestCase{
 						},
 						&libparser.NodeWhitespace{},
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("1.3"),
 							},
@@ -261,16 +341,22 @@ var ExpectedData = []DataTestCase{
 					Macro: "my_macro",
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("123"),
-						&libparser.NodeExec{
-							Name: "readlink",
-							NodeArgs: libparser.NodeArgs{
-								libparser.NewSimpleNodeString("-p"),
-								&libparser.NodeString{
-									Segments: libparser.SegmentedString{
-										&libparser.VariableStringSegment{
-											Name:       "MY_LINK",
-											Modifiers:  []libparser.StringModifier{},
-											IsOptional: false,
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeExec{
+										Name: libparser.NewSimpleNodeString("readlink"),
+										NodeArgs: libparser.NodeArgs{
+											libparser.NewSimpleNodeString("-p"),
+											&libparser.NodeString{
+												Segments: libparser.SegmentedString{
+													&libparser.VariableStringSegment{
+														Name:       "MY_LINK",
+														Modifiers:  []libparser.StringModifier{},
+														IsOptional: false,
+													},
+												},
+											},
 										},
 									},
 								},
@@ -291,7 +377,7 @@ var ExpectedData = []DataTestCase{
 			},
 			NodeChildren: libparser.NodeChildren{
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("0"),
 					},
@@ -304,7 +390,7 @@ var ExpectedData = []DataTestCase{
 					},
 					NodeChildren: libparser.NodeChildren{
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("1.1"),
 							},
@@ -320,7 +406,7 @@ var ExpectedData = []DataTestCase{
 					},
 					NodeChildren: libparser.NodeChildren{
 						&libparser.NodeExec{
-							Name: "echo",
+							Name: libparser.NewSimpleNodeString("echo"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("2.1"),
 							},
@@ -336,25 +422,25 @@ var ExpectedData = []DataTestCase{
 			Tomes: map[string]*libparser.NodeDirective{},
 			NodeChildren: libparser.NodeChildren{
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("1"),
 					},
 				},
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("2"),
 					},
 				},
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("3"),
 					},
 				},
 				&libparser.NodeExec{
-					Name: "echo",
+					Name: libparser.NewSimpleNodeString("echo"),
 					NodeArgs: libparser.NodeArgs{
 						libparser.NewSimpleNodeString("4"),
 					},
@@ -369,14 +455,14 @@ var ExpectedData = []DataTestCase{
 			NodeChildren: libparser.NodeChildren{
 				&libparser.NodePipe{
 					Source: &libparser.NodeExec{
-						Name: "echo",
+						Name: libparser.NewSimpleNodeString("echo"),
 						NodeArgs: libparser.NodeArgs{
 							libparser.NewSimpleNodeString("-e"),
-							libparser.NewSimpleNodeString(`Hello World!\n`),
+							libparser.NewSimpleNodeString("Hello World!\n"),
 						},
 					},
 					Dest: &libparser.NodeExec{
-						Name: "bat",
+						Name: libparser.NewSimpleNodeString("bat"),
 						NodeArgs: libparser.NodeArgs{
 							libparser.NewSimpleNodeString("--lang"),
 							libparser.NewSimpleNodeString("html"),
@@ -386,7 +472,7 @@ var ExpectedData = []DataTestCase{
 				&libparser.NodeWhitespace{},
 				&libparser.NodePipe{
 					Source: &libparser.NodeExec{
-						Name: "echo",
+						Name: libparser.NewSimpleNodeString("echo"),
 						NodeArgs: libparser.NodeArgs{
 							libparser.NewSimpleNodeString("123"),
 							&libparser.NodeWhitespace{IsLineBreak: true},
@@ -394,7 +480,7 @@ var ExpectedData = []DataTestCase{
 					},
 					Dest: &libparser.NodePipe{
 						Source: &libparser.NodeExec{
-							Name: "program2",
+							Name: libparser.NewSimpleNodeString("program2"),
 							NodeArgs: libparser.NodeArgs{
 								libparser.NewSimpleNodeString("input"),
 								&libparser.NodeWhitespace{IsLineBreak: true},
@@ -402,14 +488,14 @@ var ExpectedData = []DataTestCase{
 						},
 						Dest: &libparser.NodePipe{
 							Source: &libparser.NodeExec{
-								Name: "program3",
+								Name: libparser.NewSimpleNodeString("program3"),
 								NodeArgs: libparser.NodeArgs{
 									libparser.NewSimpleNodeString("input"),
 									&libparser.NodeWhitespace{IsLineBreak: true},
 								},
 							},
 							Dest: &libparser.NodeExec{
-								Name:     "bat",
+								Name:     libparser.NewSimpleNodeString("bat"),
 								NodeArgs: libparser.NodeArgs{},
 							},
 						},
@@ -423,27 +509,1728 @@ var ExpectedData = []DataTestCase{
 		Expect: &libparser.NodeRoot{
 			Tomes: map[string]*libparser.NodeDirective{},
 			NodeChildren: libparser.NodeChildren{
-				&libparser.NodeRedirect{
-					Source: &libparser.NodePipe{
+				&libparser.NodePipe{
+					Source: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("echo"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+					Dest: &libparser.NodeRedirect{
 						Source: &libparser.NodeExec{
-							Name:     "echo",
+							Name:     libparser.NewSimpleNodeString("bat"),
 							NodeArgs: libparser.NodeArgs{},
 						},
-						Dest: &libparser.NodeExec{
-							Name:     "bat",
-							NodeArgs: libparser.NodeArgs{},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:     0,
+								Mode:   libparser.REDIRECT_READ,
+								Target: libparser.NewSimpleNodeString("stdin.txt"),
+							},
+							{
+								Fd:     1,
+								Mode:   libparser.REDIRECT_WRITE,
+								Target: libparser.NewSimpleNodeString("stdout.txt"),
+							},
+							{
+								Fd:     1,
+								Mode:   libparser.REDIRECT_APPEND,
+								Target: libparser.NewSimpleNodeString("stdout.txt"),
+							},
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("go"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("test"),
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     2,
+							Mode:   libparser.REDIRECT_WRITE,
+							Target: libparser.NewSimpleNodeString("err.log"),
+						},
+						{Fd: 2, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 1},
+						{
+							Fd:     1,
+							Mode:   libparser.REDIRECT_APPEND,
+							Target: libparser.NewSimpleNodeString("all.log"),
+						},
+						{Fd: 2, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 1},
+						{
+							Fd:     3,
+							Mode:   libparser.REDIRECT_READ,
+							Target: libparser.NewSimpleNodeString("in.txt"),
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("read"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("line"),
 						},
 					},
-					Stdin:  libparser.NewSimpleNodeString("stdin.txt"),
-					Stdout: libparser.NewSimpleNodeString("stdout.txt"),
-					Stderr: libparser.NewSimpleNodeString("stderr.txt"),
+					Operations: []libparser.RedirectOperation{
+						{Fd: 0, Mode: libparser.REDIRECT_DUPLICATE_INPUT, TargetFd: 3},
+						{Fd: 4, Mode: libparser.REDIRECT_DUPLICATE_INPUT, TargetFd: 0},
+					},
 				},
 			},
 		},
 	},
-}
-
-func getModifierSafe(name libparser.ModifierName) libparser.StringModifier {
-	modifier, _ := libparser.GetModifier(name, []*libparser.NodeString{})
-	return modifier
+	{
+		Filename: "09_quotes.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "HOME",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: false,
+								},
+								&libparser.LiteralStringSegment{Contents: "/"},
+								&libparser.VariableStringSegment{
+									Name: "name",
+									Modifiers: []libparser.StringModifier{
+										getModifierSafe(libparser.MOD_TO_UPPER),
+									},
+									IsOptional: false,
+								},
+							},
+						},
+						&libparser.NodeLiteral{Contents: "single $HOME"},
+						libparser.NewSimpleNodeString("abc"),
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "tick "},
+								&libparser.VariableStringSegment{
+									Name:       "x",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: false,
+								},
+							},
+						},
+						libparser.NewSimpleNodeString(""),
+						libparser.NewSimpleNodeString("cost: $5"),
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "10_substitution.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("touch"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "out-"},
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeExec{
+										Name: libparser.NewSimpleNodeString("date"),
+										NodeArgs: libparser.NodeArgs{
+											libparser.NewSimpleNodeString("+%s"),
+										},
+									},
+								},
+								&libparser.LiteralStringSegment{Contents: ".log"},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "branch "},
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodePipe{
+										Source: &libparser.NodeExec{
+											Name: libparser.NewSimpleNodeString("git"),
+											NodeArgs: libparser.NodeArgs{
+												libparser.NewSimpleNodeString("branch"),
+											},
+										},
+										Dest: &libparser.NodeExec{
+											Name: libparser.NewSimpleNodeString("head"),
+											NodeArgs: libparser.NodeArgs{
+												libparser.NewSimpleNodeString("-1"),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeGroup{
+										IsSubshell: true,
+										NodeChildren: libparser.NodeChildren{
+											&libparser.NodeExec{
+												Name: libparser.NewSimpleNodeString("cd"),
+												NodeArgs: libparser.NodeArgs{
+													libparser.NewSimpleNodeString("dir"),
+												},
+											},
+											&libparser.NodeExec{
+												Name:     libparser.NewSimpleNodeString("make"),
+												NodeArgs: libparser.NodeArgs{},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeExec{
+										Name: libparser.NewSimpleNodeString("go"),
+										NodeArgs: libparser.NodeArgs{
+											libparser.NewSimpleNodeString("env"),
+											libparser.NewSimpleNodeString("GOPATH"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "11_arglist.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("go"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("build"),
+						libparser.NewSimpleNodeString("-o"),
+						libparser.NewSimpleNodeString("out/bin"),
+						&libparser.NodeComment{Contents: " the output"},
+						libparser.NewSimpleNodeString("-ldflags"),
+						libparser.NewSimpleNodeString("-s -w"),
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "pkg",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: false,
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeWhitespace{},
+				&libparser.NodeDirective{
+					Name: "section",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("Multi-line"),
+						&libparser.NodeComment{Contents: " directive arguments"},
+						libparser.NewSimpleNodeString("name"),
+					},
+					NodeChildren: libparser.NodeChildren{
+						&libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("echo"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("ok"),
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("*.go"),
+						libparser.NewSimpleNodeString("$HOME"),
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "12_heredoc.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("cat"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:   0,
+							Mode: libparser.REDIRECT_HEREDOC,
+							Target: &libparser.NodeHeredoc{
+								Delimiter: "EOF",
+								Body: &libparser.NodeString{
+									Segments: libparser.SegmentedString{
+										&libparser.LiteralStringSegment{Contents: "name: "},
+										&libparser.VariableStringSegment{
+											Name:       "name",
+											Modifiers:  []libparser.StringModifier{},
+											IsOptional: false,
+										},
+										&libparser.LiteralStringSegment{Contents: "\n\thome: "},
+										&libparser.VariableStringSegment{
+											Name:       "HOME",
+											Modifiers:  []libparser.StringModifier{},
+											IsOptional: false,
+										},
+										&libparser.LiteralStringSegment{Contents: "\ncost: $5\n"},
+									},
+								},
+							},
+						},
+						{
+							Fd:     1,
+							Mode:   libparser.REDIRECT_WRITE,
+							Target: libparser.NewSimpleNodeString("config.yaml"),
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("psql"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:   0,
+							Mode: libparser.REDIRECT_HEREDOC,
+							Target: &libparser.NodeHeredoc{
+								Delimiter: "SQL",
+								StripTabs: true,
+								IsLiteral: true,
+								Body:      libparser.NewSimpleNodeString("SELECT * FROM $table;\n"),
+							},
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("cat"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:   0,
+							Mode: libparser.REDIRECT_HEREDOC,
+							Target: &libparser.NodeHeredoc{
+								Delimiter: "EOF",
+								Body:      libparser.NewSimpleNodeString("body\n"),
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("ok"),
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.CommandStringSegment{
+									Node: &libparser.NodeRedirect{
+										Source: &libparser.NodeExec{
+											Name:     libparser.NewSimpleNodeString("cat"),
+											NodeArgs: libparser.NodeArgs{},
+										},
+										Operations: []libparser.RedirectOperation{
+											{
+												Fd:   0,
+												Mode: libparser.REDIRECT_HEREDOC,
+												Target: &libparser.NodeHeredoc{
+													Delimiter: "EOF",
+													Body:      libparser.NewSimpleNodeString("x\n"),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "13_chains.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeChain{
+					Left: &libparser.NodeChain{
+						Left: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("make"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+						Operator: libparser.CHAIN_AND,
+						Right: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("./run"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+					},
+					Operator: libparser.CHAIN_OR,
+					Right: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("echo"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("failed"),
+						},
+					},
+				},
+				&libparser.NodeChain{
+					Left: &libparser.NodeRedirect{
+						Source: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("make"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:     1,
+								Mode:   libparser.REDIRECT_WRITE,
+								Target: libparser.NewSimpleNodeString("build.log"),
+							},
+						},
+					},
+					Operator: libparser.CHAIN_AND,
+					Right: &libparser.NodePipe{
+						Source: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("echo"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("a | b"),
+							},
+						},
+						Dest: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("wc"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("-c"),
+							},
+						},
+					},
+				},
+				&libparser.NodeChain{
+					Left: &libparser.NodeRedirect{
+						Source: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("cat"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:   0,
+								Mode: libparser.REDIRECT_HEREDOC,
+								Target: &libparser.NodeHeredoc{
+									Delimiter: "EOF",
+									Body:      libparser.NewSimpleNodeString("body\n"),
+								},
+							},
+						},
+					},
+					Operator: libparser.CHAIN_AND,
+					Right: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("echo"),
+						NodeArgs: libparser.NodeArgs{
+							&libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.CommandStringSegment{
+										Node: &libparser.NodeChain{
+											Left: &libparser.NodeExec{
+												Name: libparser.NewSimpleNodeString("which"),
+												NodeArgs: libparser.NodeArgs{
+													libparser.NewSimpleNodeString("go"),
+												},
+											},
+											Operator: libparser.CHAIN_OR,
+											Right: &libparser.NodeExec{
+												Name: libparser.NewSimpleNodeString("echo"),
+												NodeArgs: libparser.NodeArgs{
+													libparser.NewSimpleNodeString("none"),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "14_comments.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("hi"),
+					},
+				},
+				&libparser.NodeComment{Contents: " note", IsInline: true},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("make"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     1,
+							Mode:   libparser.REDIRECT_WRITE,
+							Target: libparser.NewSimpleNodeString("log.txt"),
+						},
+					},
+				},
+				&libparser.NodeComment{Contents: " build it", IsInline: true},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("a#b"),
+					},
+				},
+				&libparser.NodeDirective{
+					Name: "assert",
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeExpr{
+							Operand: &libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.VariableStringSegment{
+										Name:       "ok",
+										Modifiers:  []libparser.StringModifier{},
+										IsOptional: false,
+									},
+								},
+							},
+						},
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+				&libparser.NodeComment{Contents: " why {", IsInline: true},
+			},
+		},
+	},
+	{
+		Filename: "15_env.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Env: []*libparser.NodeAssignment{
+						{Name: "CGO_ENABLED", Value: libparser.NewSimpleNodeString("0")},
+						{Name: "GOOS", Value: libparser.NewSimpleNodeString("linux")},
+					},
+					Name: libparser.NewSimpleNodeString("go"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("build"),
+						libparser.NewSimpleNodeString("./..."),
+					},
+				},
+				&libparser.NodeExec{
+					Env: []*libparser.NodeAssignment{
+						{
+							Name: "DIR",
+							Value: &libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.VariableStringSegment{
+										Name:       "HOME",
+										Modifiers:  []libparser.StringModifier{},
+										IsOptional: false,
+									},
+									&libparser.LiteralStringSegment{Contents: "/"},
+									&libparser.VariableStringSegment{
+										Name: "name",
+										Modifiers: []libparser.StringModifier{
+											getModifierSafe(libparser.MOD_TO_LOWER),
+										},
+										IsOptional: false,
+									},
+								},
+							},
+						},
+						{Name: "EMPTY", Value: libparser.NewSimpleNodeString("")},
+					},
+					Name: libparser.NewSimpleNodeString("./run"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("a=b"),
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "16_command_names.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: &libparser.NodeString{
+						Segments: libparser.SegmentedString{
+							&libparser.VariableStringSegment{
+								Name:       "CC",
+								Modifiers:  []libparser.StringModifier{},
+								IsOptional: false,
+							},
+						},
+					},
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("-o"),
+						libparser.NewSimpleNodeString("out"),
+						libparser.NewSimpleNodeString("main.c"),
+					},
+				},
+				&libparser.NodeExec{
+					Name: &libparser.NodeString{
+						Segments: libparser.SegmentedString{
+							&libparser.VariableStringSegment{
+								Name: "tool",
+								Modifiers: []libparser.StringModifier{
+									getModifierSafe(libparser.MOD_TO_LOWER),
+								},
+								IsOptional: false,
+							},
+						},
+					},
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("run"),
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("my tool"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("--flag"),
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:   1,
+							Mode: libparser.REDIRECT_WRITE,
+							Target: &libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.VariableStringSegment{
+										Name:       "out",
+										Modifiers:  []libparser.StringModifier{},
+										IsOptional: false,
+									},
+									&libparser.LiteralStringSegment{Contents: "/log.txt"},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: &libparser.NodeString{
+						Segments: libparser.SegmentedString{
+							&libparser.LiteralStringSegment{Contents: "./bin/"},
+							&libparser.VariableStringSegment{
+								Name:       "name",
+								Modifiers:  []libparser.StringModifier{},
+								IsOptional: false,
+							},
+						},
+					},
+					NodeArgs: libparser.NodeArgs{},
+				},
+			},
+		},
+	},
+	{
+		Filename: "17_escapes.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("tab:\t nl:\n \\ \" $HOME 🚀 \\d"),
+						&libparser.NodeLiteral{Contents: `raw \n\`},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "multi\nline "},
+								&libparser.VariableStringSegment{
+									Name:       "x",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: false,
+								},
+								&libparser.LiteralStringSegment{Contents: ` \n`},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "18_background.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeBackground{
+					Node: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("./server"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("--dev"),
+						},
+					},
+				},
+				&libparser.NodeBackground{
+					Node: &libparser.NodePipe{
+						Source: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("npm"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("run"),
+								libparser.NewSimpleNodeString("watch"),
+							},
+						},
+						Dest: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("tee"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("watch.log"),
+							},
+						},
+					},
+				},
+				&libparser.NodeComment{Contents: " watcher", IsInline: true},
+				&libparser.NodeWait{NodeArgs: libparser.NodeArgs{}},
+				&libparser.NodeWait{
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "server_pid",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: false,
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeBackground{
+					Node: &libparser.NodeChain{
+						Left: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("make"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+						Operator: libparser.CHAIN_AND,
+						Right: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("./run"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "19_globs.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("rm"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "build/"},
+								&libparser.GlobStringSegment{Pattern: "*"},
+								&libparser.LiteralStringSegment{Contents: ".o"},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "src/"},
+								&libparser.GlobStringSegment{Pattern: "**"},
+								&libparser.LiteralStringSegment{Contents: "/"},
+								&libparser.GlobStringSegment{Pattern: "*"},
+								&libparser.LiteralStringSegment{Contents: ".go"},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "file"},
+								&libparser.GlobStringSegment{Pattern: "?"},
+								&libparser.LiteralStringSegment{Contents: ".txt"},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.GlobStringSegment{Pattern: "[a-c]"},
+								&libparser.LiteralStringSegment{Contents: "x"},
+							},
+						},
+						libparser.NewSimpleNodeString("*.md"),
+						libparser.NewSimpleNodeString("*.txt"),
+						libparser.NewSimpleNodeString("[oops"),
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "20_braces.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("cp"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "config."},
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "yaml"}},
+										{&libparser.LiteralStringSegment{Contents: "yaml.bak"}},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("mkdir"),
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("-p"),
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "out/"},
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "linux"}},
+										{&libparser.LiteralStringSegment{Contents: "darwin"}},
+									},
+								},
+								&libparser.LiteralStringSegment{Contents: "/"},
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "amd64"}},
+										{&libparser.LiteralStringSegment{Contents: "arm64"}},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: ""}},
+										{&libparser.LiteralStringSegment{Contents: "pre-"}},
+									},
+								},
+								&libparser.LiteralStringSegment{Contents: "fix"},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "x"},
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.VariableStringSegment{Name: "name", Modifiers: []libparser.StringModifier{}}},
+										{&libparser.LiteralStringSegment{Contents: "a b"}},
+										{&libparser.BraceStringSegment{
+											Alternatives: []libparser.SegmentedString{
+												{&libparser.LiteralStringSegment{Contents: "c"}},
+												{&libparser.LiteralStringSegment{Contents: "d"}},
+											},
+										}},
+									},
+								},
+							},
+						},
+						libparser.NewSimpleNodeString("{x,y}"),
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "a"}},
+										{&libparser.LiteralStringSegment{Contents: "b"}},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "à"}},
+										{&libparser.LiteralStringSegment{Contents: "b"}},
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.BraceStringSegment{
+									Alternatives: []libparser.SegmentedString{
+										{&libparser.LiteralStringSegment{Contents: "Å"}},
+										{&libparser.LiteralStringSegment{Contents: "é"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "21_tilde.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("cd"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.TildeStringSegment{},
+								&libparser.LiteralStringSegment{Contents: "/.config/tool"},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("ls"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.TildeStringSegment{},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.TildeStringSegment{User: "root"},
+								&libparser.LiteralStringSegment{Contents: "/bin"},
+							},
+						},
+						libparser.NewSimpleNodeString("~"),
+						libparser.NewSimpleNodeString("~/x"),
+						libparser.NewSimpleNodeString("a~b"),
+						libparser.NewSimpleNodeString("~user:x"),
+					},
+				},
+				&libparser.NodeExec{
+					Env: []*libparser.NodeAssignment{
+						{
+							Name: "PATH",
+							Value: &libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.TildeStringSegment{},
+									&libparser.LiteralStringSegment{Contents: "/bin"},
+								},
+							},
+						},
+					},
+					Name:     libparser.NewSimpleNodeString("run"),
+					NodeArgs: libparser.NodeArgs{},
+				},
+			},
+		},
+	},
+	{
+		Filename: "22_arithmetic.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("echo"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.ArithmeticStringSegment{
+									Expression: &libparser.ArithmeticBinary{
+										Left:     &libparser.ArithmeticNumber{Value: 1},
+										Operator: libparser.ARITHMETIC_ADD,
+										Right: &libparser.ArithmeticBinary{
+											Left:     &libparser.ArithmeticNumber{Value: 2},
+											Operator: libparser.ARITHMETIC_MULTIPLY,
+											Right:    &libparser.ArithmeticNumber{Value: 3},
+										},
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.ArithmeticStringSegment{
+									Expression: &libparser.ArithmeticBinary{
+										Left: &libparser.ArithmeticGroup{
+											Inner: &libparser.ArithmeticBinary{
+												Left:     &libparser.ArithmeticVariable{Name: "major"},
+												Operator: libparser.ARITHMETIC_ADD,
+												Right:    &libparser.ArithmeticNumber{Value: 1},
+											},
+										},
+										Operator: libparser.ARITHMETIC_MODULO,
+										Right:    &libparser.ArithmeticNumber{Value: 10},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("release"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "v"},
+								&libparser.ArithmeticStringSegment{
+									Expression: &libparser.ArithmeticBinary{
+										Left:     &libparser.ArithmeticVariable{Name: "patch"},
+										Operator: libparser.ARITHMETIC_ADD,
+										Right:    &libparser.ArithmeticNumber{Value: 1},
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.ArithmeticStringSegment{
+									Expression: &libparser.ArithmeticBinary{
+										Left:     &libparser.ArithmeticVariable{Name: "count"},
+										Operator: libparser.ARITHMETIC_GREATER_EQ,
+										Right:    &libparser.ArithmeticNumber{Value: 10},
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.ArithmeticStringSegment{
+									Expression: &libparser.ArithmeticBinary{
+										Left: &libparser.ArithmeticBinary{
+											Left: &libparser.ArithmeticUnary{
+												Operator: libparser.ARITHMETIC_SUBTRACT,
+												Operand:  &libparser.ArithmeticVariable{Name: "x"},
+											},
+											Operator: libparser.ARITHMETIC_DIVIDE,
+											Right:    &libparser.ArithmeticNumber{Value: 2},
+										},
+										Operator: libparser.ARITHMETIC_NOT_EQUAL,
+										Right:    &libparser.ArithmeticNumber{Value: 0},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "23_fallbacks.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("build"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "out_dir",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: true,
+									Default: &libparser.NodeString{
+										Segments: libparser.SegmentedString{
+											&libparser.VariableStringSegment{
+												Name:      "root",
+												Modifiers: []libparser.StringModifier{},
+											},
+											&libparser.LiteralStringSegment{Contents: "/build"},
+										},
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:         "token",
+									Modifiers:    []libparser.StringModifier{},
+									IsOptional:   true,
+									ErrorMessage: libparser.NewSimpleNodeString("set TOKEN first"),
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "debug",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: true,
+									Alternate:  libparser.NewSimpleNodeString("--verbose"),
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "name",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "24_lists.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeExec{
+					Name: libparser.NewSimpleNodeString("cp"),
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:      "files",
+									Modifiers: []libparser.StringModifier{},
+									Index:     index(0),
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:       "files",
+									Modifiers:  []libparser.StringModifier{},
+									IsOptional: true,
+									Index:      index(-1),
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name: "files",
+									Modifiers: []libparser.StringModifier{
+										getModifierSafe(libparser.MOD_LENGTH),
+									},
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:      "files",
+									Modifiers: []libparser.StringModifier{},
+									IsSplat:   true,
+								},
+							},
+						},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.LiteralStringSegment{Contents: "-I"},
+								&libparser.VariableStringSegment{
+									Name:      "dirs",
+									Modifiers: []libparser.StringModifier{},
+									IsSplat:   true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "25_groups.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeGroup{
+						IsSubshell: true,
+						NodeChildren: libparser.NodeChildren{
+							&libparser.NodeExec{
+								Name: libparser.NewSimpleNodeString("cd"),
+								NodeArgs: libparser.NodeArgs{
+									libparser.NewSimpleNodeString("build"),
+								},
+							},
+							&libparser.NodeExec{
+								Name:     libparser.NewSimpleNodeString("make"),
+								NodeArgs: libparser.NodeArgs{},
+							},
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     1,
+							Mode:   libparser.REDIRECT_WRITE,
+							Target: libparser.NewSimpleNodeString("log.txt"),
+						},
+					},
+				},
+				&libparser.NodePipe{
+					Source: &libparser.NodeGroup{
+						NodeChildren: libparser.NodeChildren{
+							&libparser.NodeExec{
+								Name: libparser.NewSimpleNodeString("echo"),
+								NodeArgs: libparser.NodeArgs{
+									libparser.NewSimpleNodeString("a"),
+								},
+							},
+							&libparser.NodeExec{
+								Name: libparser.NewSimpleNodeString("echo"),
+								NodeArgs: libparser.NodeArgs{
+									libparser.NewSimpleNodeString("b"),
+								},
+							},
+						},
+					},
+					Dest: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("tee"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("out"),
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeGroup{
+						NodeChildren: libparser.NodeChildren{
+							&libparser.NodeExec{
+								Name: libparser.NewSimpleNodeString("echo"),
+								NodeArgs: libparser.NodeArgs{
+									libparser.NewSimpleNodeString("multi"),
+								},
+							},
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{Fd: 2, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 1},
+					},
+				},
+				&libparser.NodeBackground{
+					Node: &libparser.NodeGroup{
+						IsSubshell: true,
+						NodeChildren: libparser.NodeChildren{
+							&libparser.NodeChain{
+								Left: &libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("cd"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("x"),
+									},
+								},
+								Operator: libparser.CHAIN_AND,
+								Right: &libparser.NodeExec{
+									Name:     libparser.NewSimpleNodeString("ls"),
+									NodeArgs: libparser.NodeArgs{},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "26_negation.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeNot{
+					Node: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("grep"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("-q"),
+							libparser.NewSimpleNodeString("foo"),
+							libparser.NewSimpleNodeString("file"),
+						},
+					},
+				},
+				&libparser.NodeChain{
+					Left: &libparser.NodeNot{
+						Node: &libparser.NodeRedirect{
+							Source: &libparser.NodeExec{
+								Name: libparser.NewSimpleNodeString("make"),
+								NodeArgs: libparser.NodeArgs{
+									libparser.NewSimpleNodeString("test"),
+								},
+							},
+							Operations: []libparser.RedirectOperation{
+								{
+									Fd:     1,
+									Mode:   libparser.REDIRECT_WRITE,
+									Target: libparser.NewSimpleNodeString("log.txt"),
+								},
+							},
+						},
+					},
+					Operator: libparser.CHAIN_AND,
+					Right: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("echo"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("failed"),
+						},
+					},
+				},
+				&libparser.NodeCall{
+					Macro:    "ok",
+					NodeArgs: libparser.NodeArgs{},
+				},
+			},
+		},
+	},
+	{
+		Filename: "27_stage_redirects.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodePipe{
+					Source: &libparser.NodeRedirect{
+						Source: &libparser.NodeExec{
+							Name:     libparser.NewSimpleNodeString("build"),
+							NodeArgs: libparser.NodeArgs{},
+						},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:     2,
+								Mode:   libparser.REDIRECT_WRITE,
+								Target: libparser.NewSimpleNodeString("err.log"),
+							},
+						},
+					},
+					Dest: &libparser.NodeExec{
+						Name:     libparser.NewSimpleNodeString("less"),
+						NodeArgs: libparser.NodeArgs{},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("sort"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString("-r"),
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     0,
+							Mode:   libparser.REDIRECT_READ,
+							Target: libparser.NewSimpleNodeString("input.txt"),
+						},
+					},
+				},
+				&libparser.NodePipe{
+					Source: &libparser.NodeRedirect{
+						Source: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("grep"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("-c"),
+								libparser.NewSimpleNodeString("x"),
+								libparser.NewSimpleNodeString("extra.txt"),
+							},
+						},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:     0,
+								Mode:   libparser.REDIRECT_READ,
+								Target: libparser.NewSimpleNodeString("in.txt"),
+							},
+							{
+								Fd:     2,
+								Mode:   libparser.REDIRECT_WRITE,
+								Target: libparser.NewSimpleNodeString("/dev/null"),
+							},
+						},
+					},
+					Dest: &libparser.NodeRedirect{
+						Source: &libparser.NodeExec{
+							Name: libparser.NewSimpleNodeString("wc"),
+							NodeArgs: libparser.NodeArgs{
+								libparser.NewSimpleNodeString("-l"),
+							},
+						},
+						Operations: []libparser.RedirectOperation{
+							{
+								Fd:     1,
+								Mode:   libparser.REDIRECT_WRITE,
+								Target: libparser.NewSimpleNodeString("count.txt"),
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "28_process.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("jq"),
+						NodeArgs: libparser.NodeArgs{
+							libparser.NewSimpleNodeString(".name"),
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:   0,
+							Mode: libparser.REDIRECT_HERESTRING,
+							Target: &libparser.NodeString{
+								Segments: libparser.SegmentedString{
+									&libparser.VariableStringSegment{
+										Name:      "payload",
+										Modifiers: []libparser.StringModifier{},
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("diff"),
+						NodeArgs: libparser.NodeArgs{
+							&libparser.NodeProcessSubst{
+								Direction: libparser.PROCESS_OUTPUT,
+								Node: &libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("sort"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("a.txt"),
+									},
+								},
+							},
+							&libparser.NodeProcessSubst{
+								Direction: libparser.PROCESS_OUTPUT,
+								Node: &libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("sort"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("b.txt"),
+									},
+								},
+							},
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     1,
+							Mode:   libparser.REDIRECT_WRITE,
+							Target: libparser.NewSimpleNodeString("changes.diff"),
+						},
+					},
+				},
+				&libparser.NodeRedirect{
+					Source: &libparser.NodeExec{
+						Name: libparser.NewSimpleNodeString("tee"),
+						NodeArgs: libparser.NodeArgs{
+							&libparser.NodeProcessSubst{
+								Direction: libparser.PROCESS_INPUT,
+								Node: &libparser.NodeRedirect{
+									Source: &libparser.NodeExec{
+										Name:     libparser.NewSimpleNodeString("gzip"),
+										NodeArgs: libparser.NodeArgs{},
+									},
+									Operations: []libparser.RedirectOperation{
+										{
+											Fd:     1,
+											Mode:   libparser.REDIRECT_WRITE,
+											Target: libparser.NewSimpleNodeString("out.gz"),
+										},
+									},
+								},
+							},
+						},
+					},
+					Operations: []libparser.RedirectOperation{
+						{
+							Fd:     0,
+							Mode:   libparser.REDIRECT_HERESTRING,
+							Target: libparser.NewSimpleNodeString("hello"),
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "29_conditions.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeConditional{
+					Branches: []*libparser.NodeDirective{
+						{
+							Name: "if",
+							NodeArgs: libparser.NodeArgs{
+								&libparser.NodeExpr{
+									Operator: libparser.EXPR_AND,
+									Left: &libparser.NodeExpr{
+										Operator: libparser.EXPR_EQUAL,
+										Left: &libparser.NodeExpr{
+											Operand: &libparser.NodeString{
+												Segments: libparser.SegmentedString{
+													&libparser.VariableStringSegment{
+														Name:      "target",
+														Modifiers: []libparser.StringModifier{},
+													},
+												},
+											},
+										},
+										Right: &libparser.NodeExpr{
+											Operand: libparser.NewSimpleNodeString("linux"),
+										},
+									},
+									Right: &libparser.NodeExpr{
+										Operand: &libparser.NodeString{
+											Segments: libparser.SegmentedString{
+												&libparser.VariableStringSegment{
+													Name: "debug",
+													Modifiers: []libparser.StringModifier{
+														getModifierSafe(libparser.MOD_IS_EMPTY),
+														getModifierSafe(libparser.MOD_NOT),
+													},
+													IsOptional: true,
+												},
+											},
+										},
+									},
+								},
+							},
+							NodeChildren: libparser.NodeChildren{
+								&libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("echo"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("debug"),
+										libparser.NewSimpleNodeString("build"),
+									},
+								},
+							},
+						},
+					},
+				},
+				&libparser.NodeDirective{
+					Name: "assert",
+					NodeArgs: libparser.NodeArgs{
+						&libparser.NodeExpr{
+							Operator: libparser.EXPR_NOT,
+							Left: &libparser.NodeExpr{
+								Operator:  libparser.EXPR_OR,
+								IsGrouped: true,
+								Left: &libparser.NodeExpr{
+									Operator: libparser.EXPR_LESS,
+									Left: &libparser.NodeExpr{
+										Operand: &libparser.NodeString{
+											Segments: libparser.SegmentedString{
+												&libparser.VariableStringSegment{
+													Name:      "count",
+													Modifiers: []libparser.StringModifier{},
+												},
+											},
+										},
+									},
+									Right: &libparser.NodeExpr{
+										Operand: libparser.NewSimpleNodeString("10"),
+									},
+								},
+								Right: &libparser.NodeExpr{
+									Operator: libparser.EXPR_NOT_EQUAL,
+									Left: &libparser.NodeExpr{
+										Operand: &libparser.NodeString{
+											Segments: libparser.SegmentedString{
+												&libparser.VariableStringSegment{
+													Name:      "name",
+													Modifiers: []libparser.StringModifier{},
+												},
+											},
+										},
+									},
+									Right: &libparser.NodeExpr{
+										Operand: libparser.NewSimpleNodeString("tome"),
+									},
+								},
+							},
+						},
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+			},
+		},
+	},
+	{
+		Filename: "30_conditionals.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeConditional{
+					Branches: []*libparser.NodeDirective{
+						{
+							Name: "if",
+							NodeArgs: libparser.NodeArgs{
+								&libparser.NodeExpr{
+									Operator: libparser.EXPR_EQUAL,
+									Left: &libparser.NodeExpr{
+										Operand: &libparser.NodeString{
+											Segments: libparser.SegmentedString{
+												&libparser.VariableStringSegment{
+													Name:      "os",
+													Modifiers: []libparser.StringModifier{},
+												},
+											},
+										},
+									},
+									Right: &libparser.NodeExpr{
+										Operand: libparser.NewSimpleNodeString("linux"),
+									},
+								},
+							},
+							NodeChildren: libparser.NodeChildren{
+								&libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("echo"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("linux"),
+									},
+								},
+							},
+						},
+						{
+							Name: "elif",
+							NodeArgs: libparser.NodeArgs{
+								&libparser.NodeExpr{
+									Operator: libparser.EXPR_EQUAL,
+									Left: &libparser.NodeExpr{
+										Operand: &libparser.NodeString{
+											Segments: libparser.SegmentedString{
+												&libparser.VariableStringSegment{
+													Name:      "os",
+													Modifiers: []libparser.StringModifier{},
+												},
+											},
+										},
+									},
+									Right: &libparser.NodeExpr{
+										Operand: libparser.NewSimpleNodeString("darwin"),
+									},
+								},
+							},
+							NodeChildren: libparser.NodeChildren{
+								&libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("echo"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("macos"),
+									},
+								},
+							},
+						},
+						{
+							Name:     "else",
+							NodeArgs: libparser.NodeArgs{},
+							NodeChildren: libparser.NodeChildren{
+								&libparser.NodeExec{
+									Name: libparser.NewSimpleNodeString("echo"),
+									NodeArgs: libparser.NodeArgs{
+										libparser.NewSimpleNodeString("other"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	{
+		Filename: "31_typed.tome",
+		Expect: &libparser.NodeRoot{
+			Tomes: map[string]*libparser.NodeDirective{},
+			NodeChildren: libparser.NodeChildren{
+				&libparser.NodeDirective{
+					Name: "retry",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("3"),
+						libparser.NewSimpleNodeString("3"),
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+				&libparser.NodeDirective{
+					Name: "ratio",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("0.5"),
+						libparser.NewSimpleNodeString("-2"),
+						libparser.NewSimpleNodeString("1e3"),
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+				&libparser.NodeDirective{
+					Name: "parallel",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("true"),
+						&libparser.NodeLiteral{Contents: "false"},
+						&libparser.NodeString{
+							Segments: libparser.SegmentedString{
+								&libparser.VariableStringSegment{
+									Name:      "debug",
+									Modifiers: []libparser.StringModifier{},
+								},
+							},
+						},
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+				&libparser.NodeDirective{
+					Name: "timeout",
+					NodeArgs: libparser.NodeArgs{
+						libparser.NewSimpleNodeString("30s"),
+						libparser.NewSimpleNodeString("1h30m"),
+						libparser.NewSimpleNodeString("never"),
+					},
+					NodeChildren: libparser.NodeChildren{},
+				},
+			},
+		},
+	},
+}
+
+func getModifierSafe(name libparser.ModifierName) libparser.StringModifier {
+	modifier, _ := libparser.GetModifier(name, []*libparser.NodeString{})
+	return modifier
+}
+
+func index(value int) *int {
+	return &value
 }
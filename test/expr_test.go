@@ -0,0 +1,100 @@
+package libparser_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	libparser "github.com/tomefile/lib-parser"
+	"gotest.tools/assert"
+)
+
+func TestExprEval(test *testing.T) {
+	locals := libparser.Locals{
+		"target": libparser.StringValue("linux"),
+		"count":  libparser.StringValue("9"),
+		"debug":  libparser.StringValue("0"),
+	}
+
+	operand := func(segment libparser.StringSegment) *libparser.NodeExpr {
+		return &libparser.NodeExpr{
+			Operand: &libparser.NodeString{Segments: libparser.SegmentedString{segment}},
+		}
+	}
+	variable := func(name string) *libparser.NodeExpr {
+		return operand(&libparser.VariableStringSegment{Name: name})
+	}
+	literal := func(contents string) *libparser.NodeExpr {
+		return operand(&libparser.LiteralStringSegment{Contents: contents})
+	}
+	binary := func(left *libparser.NodeExpr, operator libparser.ExprOperator, right *libparser.NodeExpr) *libparser.NodeExpr {
+		return &libparser.NodeExpr{Operator: operator, Left: left, Right: right}
+	}
+	eval := func(expression *libparser.NodeExpr) bool {
+		value, err := expression.Eval(locals, nil)
+		assert.NilError(test, err)
+		return value
+	}
+
+	assert.Equal(test, eval(binary(variable("target"), libparser.EXPR_EQUAL, literal("linux"))), true)
+	assert.Equal(test, eval(binary(variable("target"), libparser.EXPR_NOT_EQUAL, literal("linux"))), false)
+	assert.Equal(test, eval(variable("debug")), false)
+	assert.Equal(test, eval(&libparser.NodeExpr{Operator: libparser.EXPR_NOT, Left: variable("debug")}), true)
+
+	// 9 < 10 as numbers, but not as strings
+	assert.Equal(test, eval(binary(variable("count"), libparser.EXPR_LESS, literal("10"))), true)
+	assert.Equal(test, eval(binary(literal("b"), libparser.EXPR_GREATER, literal("a"))), true)
+
+	// The right side is never evaluated, so the missing variable isn't an error
+	assert.Equal(test, eval(binary(variable("debug"), libparser.EXPR_AND, variable("missing"))), false)
+	assert.Equal(test, eval(binary(variable("target"), libparser.EXPR_OR, variable("missing"))), true)
+
+	_, err := binary(variable("target"), libparser.EXPR_AND, variable("missing")).Eval(locals, nil)
+	assert.ErrorContains(test, err, "missing")
+}
+
+func TestConditionalMatch(test *testing.T) {
+	defer libparser.CloseAll()
+
+	file, err := libparser.OpenFile(filepath.Join("data", "30_conditionals.tome"))
+	assert.NilError(test, err)
+
+	parser := libparser.New(file)
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+	conditional := parser.Result.NodeChildren[0].(*libparser.NodeConditional)
+
+	match := func(os string) string {
+		branch, err := conditional.Match(libparser.Locals{"os": libparser.StringValue(os)}, nil)
+		assert.NilError(test, err)
+		return branch.Name
+	}
+
+	assert.Equal(test, match("linux"), "if")
+	assert.Equal(test, match("darwin"), "elif")
+	assert.Equal(test, match("windows"), "else")
+}
+
+func TestExprWithoutSpaces(test *testing.T) {
+	sources := map[string]string{
+		`:assert "$x"=="y"`:            `$x == y`,
+		`:assert $a!=b&&$c<10`:         `$a != b && $c < 10`,
+		`:assert ($a==b)||!$c`:         `($a == b) || ! $c`,
+		`:assert $(echo a==b) == a==b`: `"$(echo a==b)" == a == b`,
+		`:assert a\==b`:                `a\==b`,
+		`:assert 5>3`:                  `5 > 3`,
+		`:assert $n<10`:                `$n < 10`,
+	}
+
+	for source, expected := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		if derr := parser.Run(); derr != nil {
+			derr.Print(test.Output())
+			test.FailNow()
+		}
+		directive := parser.Result.NodeChildren[0].(*libparser.NodeDirective)
+		assert.Equal(test, directive.NodeArgs[0].String(), expected, source)
+	}
+}
@@ -195,7 +195,7 @@ func TestModifier(test *testing.T) {
 			assert.DeepEqual(
 				test,
 				test_case.Expect,
-				modifier.Call(libparser.Locals{}, test_case.Input),
+				modifier.Call(libparser.Locals{}, nil, test_case.Input),
 			)
 		})
 	}
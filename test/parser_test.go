@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	libescapes "github.com/bbfh-dev/lib-ansi-escapes"
@@ -17,7 +18,8 @@ import (
 
 var IgnoredOptions = []cmp.Option{
 	cmpopts.IgnoreTypes(libparser.NodeContext{}),
-	cmpopts.IgnoreFields(libparser.StringModifier{}, "Call"),
+	cmpopts.IgnoreFields(libparser.StringModifier{}, "Call", "CallList"),
+	cmpopts.IgnoreFields(libparser.NodeString{}, "Raw"),
 }
 
 func TestAll(test *testing.T) {
@@ -66,6 +68,19 @@ func TestAll(test *testing.T) {
 	}
 }
 
+func TestStrictASCII(test *testing.T) {
+	defer libparser.CloseAll()
+
+	file, err := libparser.OpenFile(filepath.Join("data", "01_syntax_unicode.tome"))
+	assert.NilError(test, err)
+
+	parser := libparser.New(file)
+	parser.StrictASCII = true
+	derr := parser.Run()
+	assert.Assert(test, derr != nil)
+	assert.Equal(test, derr.Details, "unexpected 'ö' in a variable expansion")
+}
+
 func TestPostProcessor(test *testing.T) {
 	defer libparser.CloseAll()
 
@@ -102,3 +117,233 @@ func TestPostProcessor(test *testing.T) {
 		},
 	}, IgnoredOptions...)
 }
+
+func TestRawSpelling(test *testing.T) {
+	defer libparser.CloseAll()
+
+	file, err := libparser.OpenFile(filepath.Join("data", "17_escapes.tome"))
+	assert.NilError(test, err)
+
+	parser := libparser.New(file)
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+
+	exec := parser.Result.NodeChildren[0].(*libparser.NodeExec)
+	assert.Equal(test, exec.Name.Raw, "echo")
+	assert.Equal(
+		test,
+		exec.NodeArgs[0].(*libparser.NodeString).Raw,
+		`"tab:\t nl:\n \\ \" \$HOME \u{1F680} \d"`,
+	)
+	assert.Equal(test, exec.NodeArgs[2].(*libparser.NodeString).Raw, "`multi\nline $x \\n`")
+}
+
+type stringFile struct {
+	*strings.Reader
+}
+
+func (stringFile) Name() string {
+	return "string.tome"
+}
+
+func (stringFile) Close() error {
+	return nil
+}
+
+func TestStringRoundTrip(test *testing.T) {
+	sources := map[string]string{
+		`config.{yaml,yaml.bak}`: `config.{yaml,yaml.bak}`,
+		`[abc].txt`:              `[abc].txt`,
+		`"my dir"/*.go`:          `"my dir/"*.go`,
+		`~/bin`:                  `~/bin`,
+		`"~"`:                    `"~"`,
+		`"*.go"`:                 `"*.go"`,
+		`"say \"hi\" \$x"`:       `"say \"hi\" \$x"`,
+	}
+
+	parse := func(source string) *libparser.NodeString {
+		parser := libparser.New(stringFile{strings.NewReader("echo " + source + "\n")})
+		if derr := parser.Run(); derr != nil {
+			derr.Print(test.Output())
+			test.FailNow()
+		}
+		return parser.Result.NodeChildren[0].(*libparser.NodeExec).NodeArgs[0].(*libparser.NodeString)
+	}
+
+	for source, expected := range sources {
+		node := parse(source)
+		assert.Equal(test, node.String(), expected, source)
+		assert.DeepEqual(test, parse(node.String()).Segments, node.Segments, IgnoredOptions...)
+	}
+}
+
+func TestOrphanedBranches(test *testing.T) {
+	sources := map[string]string{
+		":else {}":                       "must follow the body",
+		"echo hi\n:elif $x {}":           "must follow the body",
+		":if $x {} :else {} :else {}":    "can't follow ':else'",
+		":if $x {} :else {} :elif $y {}": "can't follow ':else'",
+		":if $x {} :else $y {}":          "doesn't take a condition",
+	}
+
+	for source, message := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		derr := parser.Run()
+		assert.Assert(test, derr != nil, source)
+		assert.Assert(test, strings.Contains(derr.Details, message), "%q: %s", source, derr.Details)
+	}
+}
+
+func TestConditionErrors(test *testing.T) {
+	sources := map[string]string{
+		":assert $a foo":          "unexpected 'f' after a condition",
+		":if $a $b { echo x }":    "unexpected '$' after a condition",
+		":if ($a == b) c {}":      "unexpected 'c' after a condition",
+		":assert $a == 'b' 'c'\n": "unexpected '\\'' after a condition",
+	}
+
+	for source, message := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		derr := parser.Run()
+		assert.Assert(test, derr != nil, source)
+		assert.Assert(test, strings.Contains(derr.Details, message), "%q: %s", source, derr.Details)
+	}
+}
+
+func TestAssignmentErrors(test *testing.T) {
+	sources := map[string]string{
+		"x=1":        "missing a command after \"x=1\"",
+		"x=1\n":      "missing a command after \"x=1\"",
+		"x=1;":       "missing a command after \"x=1\"",
+		"A=1 B=":     "missing a command after \"B=\"",
+		"A=1 B=\n":   "missing a command after \"B=\"",
+		"GOOS=linux": "missing a command after \"GOOS=linux\"",
+	}
+
+	for source, message := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		derr := parser.Run()
+		assert.Assert(test, derr != nil, source)
+		assert.Assert(test, strings.Contains(derr.Details, message), "%q: %s", source, derr.Details)
+	}
+}
+
+func TestExpansionErrors(test *testing.T) {
+	sources := map[string]string{
+		"echo x $(a\n":    "missing ')' at the end of a command substitution",
+		"echo $(a}\n":     "expected ')' at the end of a command substitution",
+		"echo $()\n":      "missing a command inside of a command substitution",
+		"diff <(sort a\n": "missing ')' at the end of a process substitution",
+		"diff <(sort a":   "missing ')' at the end of a process substitution",
+		"echo $((1+":      "missing '))' at the end of an arithmetic expansion",
+		"echo $((1+\n":    "missing '))' at the end of an arithmetic expansion",
+	}
+
+	for source, message := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		derr := parser.Run()
+		assert.Assert(test, derr != nil, source)
+		assert.Assert(test, strings.Contains(derr.Details, message), "%q: %s", source, derr.Details)
+	}
+}
+
+func TestHeredocErrors(test *testing.T) {
+	sources := map[string]string{
+		"cat <<EOF":         "missing heredoc delimiter \"EOF\"",
+		"cat <<EOF # c":     "missing heredoc delimiter \"EOF\"",
+		"A<<0 ":             "missing heredoc delimiter \"0\"",
+		"cat <<EOF\nbody":   "missing \"EOF\" at the end of a heredoc",
+		"cat <<":            "missing a heredoc delimiter after '<<'",
+		"echo $(cat <<EOF)": "missing heredoc delimiter \"EOF\"",
+	}
+
+	for source, message := range sources {
+		parser := libparser.New(stringFile{strings.NewReader(source)})
+		derr := parser.Run()
+		assert.Assert(test, derr != nil, source)
+		assert.Assert(test, strings.Contains(derr.Details, message), "%q: %s", source, derr.Details)
+	}
+
+	assert.Equal(test, (&libparser.NodeHeredoc{Delimiter: "EOF"}).String(), "<<EOF\nEOF")
+}
+
+func TestHeredocHooks(test *testing.T) {
+	var bodies []string
+	parser := libparser.New(stringFile{strings.NewReader("cat <<EOF; echo ok\nbody\nEOF\n")})
+	parser.Hooks = []libparser.Hook{
+		func(node libparser.Node) (libparser.Node, *liberrors.DetailedError) {
+			if redirect, ok := node.(*libparser.NodeRedirect); ok {
+				heredoc := redirect.Operations[0].Target.(*libparser.NodeHeredoc)
+				assert.Assert(test, heredoc.Body != nil)
+				bodies = append(bodies, heredoc.Body.Segments.String())
+			}
+			return node, nil
+		},
+	}
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+
+	// The hook only sees the redirect once the body on the next line has been read
+	assert.DeepEqual(test, bodies, []string{"body\n"})
+}
+
+func TestConditionalHooks(test *testing.T) {
+	source := ":if $x {\n\techo x\n}\n\n# otherwise\n:else {\n\techo y\n}\necho z\n"
+
+	var branches []int
+	parser := libparser.New(stringFile{strings.NewReader(source)})
+	parser.Hooks = []libparser.Hook{
+		libparser.ExcludeHook[*libparser.NodeWhitespace],
+		func(node libparser.Node) (libparser.Node, *liberrors.DetailedError) {
+			if conditional, ok := node.(*libparser.NodeConditional); ok {
+				branches = append(branches, len(conditional.Branches))
+			}
+			return node, nil
+		},
+	}
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+
+	// The hook only sees the conditional once all of its branches are linked
+	assert.DeepEqual(test, branches, []int{2})
+	assert.Equal(test, len(parser.Result.NodeChildren), 3)
+	assert.Equal(test, parser.Result.NodeChildren[1].(*libparser.NodeComment).Contents, " otherwise")
+
+	parser = libparser.New(stringFile{strings.NewReader(source)})
+	parser.Hooks = []libparser.Hook{
+		libparser.ExcludeHook[*libparser.NodeConditional],
+	}
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+	_, is_conditional := parser.Result.NodeChildren[0].(*libparser.NodeConditional)
+	assert.Assert(test, !is_conditional)
+}
+
+func TestTrailingCommentContext(test *testing.T) {
+	parser := libparser.New(stringFile{strings.NewReader("echo hi # note\necho bye | cat # end\n")})
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+
+	children := parser.Result.NodeChildren
+	assert.Equal(test, len(children), 4)
+	for i := 0; i < len(children); i += 2 {
+		statement := children[i].Context()
+		comment := children[i+1].(*libparser.NodeComment).Context()
+		assert.Assert(
+			test,
+			statement.OffsetEnd <= comment.OffsetStart,
+			"%T [%d-%d] overlaps its comment [%d-%d]",
+			children[i], statement.OffsetStart, statement.OffsetEnd, comment.OffsetStart, comment.OffsetEnd,
+		)
+	}
+}
@@ -0,0 +1,44 @@
+package libparser_test
+
+import (
+	"testing"
+
+	libparser "github.com/tomefile/lib-parser"
+	"gotest.tools/assert"
+)
+
+func TestRedirectAccessors(test *testing.T) {
+	out := libparser.NewSimpleNodeString("out.log")
+	in := libparser.NewSimpleNodeString("in.txt")
+
+	node := &libparser.NodeRedirect{
+		Operations: []libparser.RedirectOperation{
+			{Fd: 2, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 1},
+			{Fd: 1, Mode: libparser.REDIRECT_WRITE, Target: out},
+			{Fd: 0, Mode: libparser.REDIRECT_READ, Target: in},
+		},
+	}
+	assert.Equal(test, node.Stdin(), in)
+	assert.Equal(test, node.Stdout(), out)
+	assert.Assert(test, node.Stderr() == nil)
+
+	node.Operations = append(node.Operations, libparser.RedirectOperation{
+		Fd:       2,
+		Mode:     libparser.REDIRECT_DUPLICATE,
+		TargetFd: 1,
+	})
+	assert.Equal(test, node.Stderr(), out)
+}
+
+func TestRedirectOperationString(test *testing.T) {
+	operations := map[string]libparser.RedirectOperation{
+		"2>&1": {Fd: 2, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 1},
+		">&3":  {Fd: 1, Mode: libparser.REDIRECT_DUPLICATE, TargetFd: 3},
+		"<&3":  {Fd: 0, Mode: libparser.REDIRECT_DUPLICATE_INPUT, TargetFd: 3},
+		"4<&0": {Fd: 4, Mode: libparser.REDIRECT_DUPLICATE_INPUT, TargetFd: 0},
+	}
+
+	for expected, operation := range operations {
+		assert.Equal(test, operation.String(), expected)
+	}
+}
@@ -0,0 +1,382 @@
+package libparser_test
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	libparser "github.com/tomefile/lib-parser"
+	"gotest.tools/assert"
+)
+
+type echoRunner struct{}
+
+func (echoRunner) RunCommand(node libparser.Node, locals libparser.Locals) (string, error) {
+	return node.String() + "\n\n", nil
+}
+
+func TestCommandStringSegment(test *testing.T) {
+	segment := &libparser.CommandStringSegment{
+		Node: &libparser.NodeExec{
+			Name:     libparser.NewSimpleNodeString("date"),
+			NodeArgs: libparser.NodeArgs{libparser.NewSimpleNodeString("+%s")},
+		},
+	}
+
+	_, err := segment.Eval(libparser.Locals{}, nil)
+	assert.ErrorContains(test, err, "no command runner")
+
+	value, err := segment.Eval(libparser.Locals{}, &libparser.EvalOptions{Runner: echoRunner{}})
+	assert.NilError(test, err)
+	assert.Equal(test, value, "date +%s")
+
+	// A subshell is spaced out so it doesn't read as '$(('
+	segment.Node = &libparser.NodeGroup{
+		IsSubshell:   true,
+		NodeChildren: libparser.NodeChildren{segment.Node},
+	}
+	assert.Equal(test, segment.Segment(), "$( ( date +%s; ) )")
+}
+
+func TestGlobStringSegment(test *testing.T) {
+	options := &libparser.EvalOptions{
+		GlobFS: fstest.MapFS{
+			"build/a.o":        {},
+			"build/b.o":        {},
+			"build/c.txt":      {},
+			"src/x.go":         {},
+			"src/pkg/y.go":     {},
+			"src/.hidden/z.go": {},
+		},
+	}
+
+	glob := func(segments ...libparser.StringSegment) []string {
+		node := &libparser.NodeString{Segments: segments}
+		words, err := node.EvalWords(libparser.Locals{"dir": libparser.StringValue("build")}, options)
+		assert.NilError(test, err)
+		return words
+	}
+
+	assert.DeepEqual(
+		test,
+		glob(
+			&libparser.VariableStringSegment{Name: "dir"},
+			&libparser.LiteralStringSegment{Contents: "/"},
+			&libparser.GlobStringSegment{Pattern: "*"},
+			&libparser.LiteralStringSegment{Contents: ".o"},
+		),
+		[]string{"build/a.o", "build/b.o"},
+	)
+	assert.DeepEqual(
+		test,
+		glob(
+			&libparser.LiteralStringSegment{Contents: "src/"},
+			&libparser.GlobStringSegment{Pattern: "**"},
+			&libparser.LiteralStringSegment{Contents: "/"},
+			&libparser.GlobStringSegment{Pattern: "*"},
+			&libparser.LiteralStringSegment{Contents: ".go"},
+		),
+		[]string{"src/pkg/y.go", "src/x.go"},
+	)
+
+	missing := []libparser.StringSegment{
+		&libparser.LiteralStringSegment{Contents: "out/"},
+		&libparser.GlobStringSegment{Pattern: "*"},
+	}
+	assert.DeepEqual(test, glob(missing...), []string{"out/*"})
+
+	options.GlobNoMatch = libparser.GLOB_DROP
+	assert.DeepEqual(test, glob(missing...), []string{})
+
+	options.GlobNoMatch = libparser.GLOB_ERROR
+	node := &libparser.NodeString{Segments: missing}
+	_, err := node.EvalWords(libparser.Locals{}, options)
+	assert.ErrorContains(test, err, "no files match")
+}
+
+func TestBraceStringSegment(test *testing.T) {
+	brace := func(alternatives ...string) *libparser.BraceStringSegment {
+		segment := &libparser.BraceStringSegment{}
+		for _, alternative := range alternatives {
+			segment.Alternatives = append(segment.Alternatives, libparser.SegmentedString{
+				&libparser.LiteralStringSegment{Contents: alternative},
+			})
+		}
+		return segment
+	}
+
+	node := &libparser.NodeString{
+		Segments: libparser.SegmentedString{
+			&libparser.LiteralStringSegment{Contents: "out/"},
+			brace("linux", "darwin"),
+			&libparser.LiteralStringSegment{Contents: "/"},
+			brace("amd64", "arm64"),
+		},
+	}
+
+	words, err := node.EvalWords(libparser.Locals{}, nil)
+	assert.NilError(test, err)
+	assert.DeepEqual(test, words, []string{
+		"out/linux/amd64",
+		"out/linux/arm64",
+		"out/darwin/amd64",
+		"out/darwin/arm64",
+	})
+
+	value, err := node.Eval(libparser.Locals{}, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "out/{linux,darwin}/{amd64,arm64}")
+}
+
+func TestTildeStringSegment(test *testing.T) {
+	options := &libparser.EvalOptions{
+		LookupHomeDir: func(name string) (string, error) {
+			if name == "" {
+				return "/home/me", nil
+			}
+			return "/home/" + name, nil
+		},
+	}
+
+	node := &libparser.NodeString{
+		Segments: libparser.SegmentedString{
+			&libparser.TildeStringSegment{},
+			&libparser.LiteralStringSegment{Contents: "/.config/tool"},
+		},
+	}
+	value, err := node.Eval(libparser.Locals{}, options)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "/home/me/.config/tool")
+
+	value, err = (&libparser.TildeStringSegment{User: "root"}).Eval(libparser.Locals{}, options)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "/home/root")
+}
+
+func TestArithmeticStringSegment(test *testing.T) {
+	locals := libparser.Locals{
+		"patch": libparser.StringValue("41"),
+		"zero":  libparser.StringValue("0"),
+		"name":  libparser.StringValue("tome"),
+	}
+
+	eval := func(expression libparser.ArithmeticNode) (string, error) {
+		segment := &libparser.ArithmeticStringSegment{Expression: expression}
+		return segment.Eval(locals, nil)
+	}
+
+	value, err := eval(&libparser.ArithmeticBinary{
+		Left: &libparser.ArithmeticGroup{
+			Inner: &libparser.ArithmeticBinary{
+				Left:     &libparser.ArithmeticVariable{Name: "patch"},
+				Operator: libparser.ARITHMETIC_ADD,
+				Right:    &libparser.ArithmeticNumber{Value: 1},
+			},
+		},
+		Operator: libparser.ARITHMETIC_MULTIPLY,
+		Right: &libparser.ArithmeticUnary{
+			Operator: libparser.ARITHMETIC_SUBTRACT,
+			Operand:  &libparser.ArithmeticNumber{Value: 2},
+		},
+	})
+	assert.NilError(test, err)
+	assert.Equal(test, value, "-84")
+
+	value, err = eval(&libparser.ArithmeticBinary{
+		Left:     &libparser.ArithmeticVariable{Name: "patch"},
+		Operator: libparser.ARITHMETIC_GREATER_EQ,
+		Right:    &libparser.ArithmeticNumber{Value: 10},
+	})
+	assert.NilError(test, err)
+	assert.Equal(test, value, "1")
+
+	_, err = eval(&libparser.ArithmeticBinary{
+		Left:     &libparser.ArithmeticNumber{Value: 1},
+		Operator: libparser.ARITHMETIC_MODULO,
+		Right:    &libparser.ArithmeticVariable{Name: "zero"},
+	})
+	assert.ErrorContains(test, err, "division by zero")
+
+	_, err = eval(&libparser.ArithmeticVariable{Name: "name"})
+	assert.ErrorContains(test, err, "not an integer")
+}
+
+func TestVariableFallbacks(test *testing.T) {
+	locals := libparser.Locals{
+		"root":  libparser.StringValue("/src"),
+		"debug": libparser.StringValue("1"),
+		"empty": libparser.StringValue(""),
+	}
+
+	fallback := &libparser.VariableStringSegment{
+		Name: "out_dir",
+		Default: &libparser.NodeString{
+			Segments: libparser.SegmentedString{
+				&libparser.VariableStringSegment{Name: "root"},
+				&libparser.LiteralStringSegment{Contents: "/build"},
+			},
+		},
+	}
+	value, err := fallback.Eval(locals, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "/src/build")
+	assert.Equal(test, locals["out_dir"].String(), "/src/build")
+
+	fallback.Default = libparser.NewSimpleNodeString("/tmp")
+	value, err = fallback.Eval(nil, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "/tmp")
+
+	alternate := &libparser.VariableStringSegment{
+		Name:      "debug",
+		Alternate: libparser.NewSimpleNodeString("--verbose"),
+	}
+	value, err = alternate.Eval(locals, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "--verbose")
+
+	// Empty is still set
+	alternate.Name = "empty"
+	value, err = alternate.Eval(locals, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "--verbose")
+
+	alternate.Name = "missing"
+	value, err = alternate.Eval(locals, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "")
+
+	required := &libparser.VariableStringSegment{
+		Name:         "empty",
+		ErrorMessage: libparser.NewSimpleNodeString("set it first"),
+	}
+	// Empty is still set
+	value, err = required.Eval(locals, nil)
+	assert.NilError(test, err)
+	assert.Equal(test, value, "")
+
+	required.Name = "missing"
+	_, err = required.Eval(locals, nil)
+	assert.ErrorContains(test, err, "set it first")
+}
+
+func TestListVariables(test *testing.T) {
+	locals := libparser.Locals{
+		"files": libparser.ListValue("a.go", "b.go", "c.go"),
+		"dirs":  libparser.ListValue("include", "vendor"),
+		"none":  libparser.ListValue(),
+		"name":  libparser.StringValue("tome"),
+	}
+	length, _ := libparser.GetModifier(libparser.MOD_LENGTH, []*libparser.NodeString{})
+	upper, _ := libparser.GetModifier(libparser.MOD_TO_UPPER, []*libparser.NodeString{})
+
+	eval := func(segment *libparser.VariableStringSegment) string {
+		value, err := segment.Eval(locals, nil)
+		assert.NilError(test, err)
+		return value
+	}
+	words := func(segments ...libparser.StringSegment) []string {
+		node := &libparser.NodeString{Segments: segments}
+		words, err := node.EvalWords(locals, nil)
+		assert.NilError(test, err)
+		return words
+	}
+
+	assert.Equal(test, eval(&libparser.VariableStringSegment{Name: "files", Index: index(0)}), "a.go")
+	assert.Equal(test, eval(&libparser.VariableStringSegment{Name: "files", Index: index(-1)}), "c.go")
+	assert.Equal(test, eval(&libparser.VariableStringSegment{Name: "files"}), "a.go b.go c.go")
+	assert.Equal(test, eval(&libparser.VariableStringSegment{
+		Name:      "files",
+		Modifiers: []libparser.StringModifier{length},
+	}), "3")
+	assert.Equal(test, eval(&libparser.VariableStringSegment{
+		Name:      "name",
+		Modifiers: []libparser.StringModifier{length},
+	}), "4")
+	assert.Equal(test, eval(&libparser.VariableStringSegment{
+		Name:      "files",
+		Index:     index(1),
+		Modifiers: []libparser.StringModifier{upper},
+	}), "B.GO")
+
+	_, err := (&libparser.VariableStringSegment{Name: "files", Index: index(3)}).Eval(locals, nil)
+	assert.ErrorContains(test, err, "out of range")
+
+	assert.DeepEqual(
+		test,
+		words(&libparser.VariableStringSegment{Name: "files", IsSplat: true}),
+		[]string{"a.go", "b.go", "c.go"},
+	)
+	assert.DeepEqual(
+		test,
+		words(
+			&libparser.LiteralStringSegment{Contents: "-I"},
+			&libparser.VariableStringSegment{Name: "dirs", IsSplat: true},
+		),
+		[]string{"-Iinclude", "-Ivendor"},
+	)
+	assert.DeepEqual(
+		test,
+		words(&libparser.VariableStringSegment{Name: "none", IsSplat: true}),
+		[]string{},
+	)
+}
+
+func TestTypedLiterals(test *testing.T) {
+	defer libparser.CloseAll()
+
+	file, err := libparser.OpenFile(filepath.Join("data", "31_typed.tome"))
+	assert.NilError(test, err)
+
+	parser := libparser.New(file)
+	if derr := parser.Run(); derr != nil {
+		derr.Print(test.Output())
+		test.FailNow()
+	}
+
+	kinds := []libparser.LiteralKind{}
+	for _, child := range parser.Result.NodeChildren {
+		for _, arg := range child.(*libparser.NodeDirective).NodeArgs {
+			if arg, ok := arg.(*libparser.NodeString); ok {
+				kinds = append(kinds, arg.Kind())
+			}
+		}
+	}
+	assert.DeepEqual(test, kinds, []libparser.LiteralKind{
+		libparser.LITERAL_INTEGER, libparser.LITERAL_STRING,
+		libparser.LITERAL_FLOAT, libparser.LITERAL_INTEGER, libparser.LITERAL_FLOAT,
+		libparser.LITERAL_BOOLEAN, libparser.LITERAL_STRING,
+		libparser.LITERAL_DURATION, libparser.LITERAL_DURATION, libparser.LITERAL_STRING,
+	})
+
+	arg := func(row, column int) *libparser.NodeString {
+		return parser.Result.NodeChildren[row].(*libparser.NodeDirective).NodeArgs[column].(*libparser.NodeString)
+	}
+
+	retries, err := arg(0, 0).Int()
+	assert.NilError(test, err)
+	assert.Equal(test, retries, int64(3))
+
+	_, err = arg(0, 1).Int()
+	assert.ErrorContains(test, err, "expected integer")
+
+	ratio, err := arg(1, 1).Float()
+	assert.NilError(test, err)
+	assert.Equal(test, ratio, -2.0)
+
+	parallel, err := arg(2, 0).Bool()
+	assert.NilError(test, err)
+	assert.Equal(test, parallel, true)
+
+	timeout, err := arg(3, 1).Duration()
+	assert.NilError(test, err)
+	assert.Equal(test, timeout, 90*time.Minute)
+
+	// The original spelling is kept
+	assert.Equal(test, arg(1, 2).String(), "1e3")
+
+	// Strings that weren't parsed might have been quoted, e.g. '3'
+	quoted := (&libparser.NodeLiteral{Contents: "3"}).ToStringNode()
+	assert.Equal(test, quoted.Kind(), libparser.LITERAL_STRING)
+}